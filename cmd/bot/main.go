@@ -2,15 +2,25 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/joho/godotenv"
 	"github.com/maxik12233/english-helper-telegrambot/pkg/db"
+	"github.com/maxik12233/english-helper-telegrambot/pkg/dictionary"
 	gTranslate "github.com/maxik12233/english-helper-telegrambot/pkg/google-translate-sdk"
 	"github.com/maxik12233/english-helper-telegrambot/pkg/logger"
+	"github.com/maxik12233/english-helper-telegrambot/pkg/ocr"
+	offlineTranslate "github.com/maxik12233/english-helper-telegrambot/pkg/offline-translate"
+	"github.com/maxik12233/english-helper-telegrambot/pkg/stt"
 	"github.com/maxik12233/english-helper-telegrambot/pkg/telegram"
+	"github.com/maxik12233/english-helper-telegrambot/pkg/tts"
 	"go.uber.org/zap"
 )
 
@@ -30,34 +40,271 @@ func main() {
 		panic(err)
 	}
 
-	client, err := db.InitMongoConnection()
+	repo, closeRepo, err := newRepository()
 	if err != nil {
-		log.Fatal("Error while initializing mongoDB connection", zap.Error(err))
+		log.Fatal("Error while initializing database", zap.Error(err))
 		panic("DB error")
 	}
-	if client == nil {
-		log.Fatal("Mongo connection is nil, failed creating mongo connection", zap.Error(err))
-		panic("Mongo connection is nil")
-	}
 	defer func() {
-		if err := client.Disconnect(context.TODO()); err != nil {
-			log.Fatal("Failed closing mongo connection.", zap.Error(err))
+		if err := closeRepo(); err != nil {
+			log.Fatal("Failed closing database connection.", zap.Error(err))
 			panic(err)
 		}
 	}()
 
-	repo := db.NewMongoRepo(client.Database("bot"))
+	if err := setMessageRetention(repo); err != nil {
+		log.Fatal("Failed setting message retention.", zap.Error(err))
+		panic(err)
+	}
 
-	translater, err := gTranslate.NewClient(gTranslate.Config{
-		Key: os.Getenv("GTRANSLATE_API_KEY"),
-	}, http.DefaultClient)
+	offline, err := newOfflineTranslater()
+	if err != nil {
+		log.Fatal("Failed loading offline dictionary.", zap.Error(err))
+		panic(err)
+	}
+
+	var translater gTranslate.IClient
+	if offline != nil && os.Getenv("GTRANSLATE_API_KEY") == "" && os.Getenv("GTRANSLATE_CREDENTIALS") == "" {
+		log.Info("Google translate is not configured, using offline dictionary only")
+		translater = offline
+	} else {
+		translater, err = newTranslater()
+		if err != nil {
+			log.Fatal("Failed creating new translater instance.", zap.Error(err))
+			panic(err)
+		}
+		if offline != nil {
+			translater = gTranslate.NewFallbackClient(translater, offline)
+		}
+	}
+
+	var opts []telegram.Option
+	if path := os.Getenv("DICTIONARY_PATH"); path != "" {
+		dict, err := dictionary.LoadWiktionary(path, dictionaryLanguages()...)
+		if err != nil {
+			log.Fatal("Failed loading dictionary.", zap.Error(err))
+			panic(err)
+		}
+		opts = append(opts, telegram.WithDictionary(dict))
+	}
+
+	speech, err := newSpeechProvider()
+	if err != nil {
+		log.Fatal("Failed creating speech provider.", zap.Error(err))
+		panic(err)
+	}
+	if speech != nil {
+		opts = append(opts, telegram.WithSpeech(speech))
+	}
+
+	transcriber, err := newTranscriber()
+	if err != nil {
+		log.Fatal("Failed creating transcriber.", zap.Error(err))
+		panic(err)
+	}
+	if transcriber != nil {
+		opts = append(opts, telegram.WithTranscriber(transcriber))
+	}
+
+	recognizer, err := newRecognizer()
 	if err != nil {
-		log.Fatal("Failed creating new translater instance.", zap.Error(err))
+		log.Fatal("Failed creating text recognizer.", zap.Error(err))
 		panic(err)
 	}
+	if recognizer != nil {
+		opts = append(opts, telegram.WithRecognizer(recognizer))
+	}
 
-	bot := telegram.NewBot(botAPI, repo, translater)
+	bot := telegram.NewBot(botAPI, repo, translater, opts...)
 
 	log.Info("App initialized, starting bot service")
 	bot.Start()
 }
+
+// newTranslater uses Cloud Translation v3 when service account credentials
+// are configured, and the v2 api with a simple key otherwise.
+func newTranslater() (gTranslate.IClient, error) {
+	credentialsFile := os.Getenv("GTRANSLATE_CREDENTIALS")
+	if credentialsFile == "" {
+		return gTranslate.NewClient(gTranslate.Config{
+			Key:       os.Getenv("GTRANSLATE_API_KEY"),
+			RateLimit: 10,
+			Burst:     5,
+		}, http.DefaultClient)
+	}
+
+	credentials, err := os.ReadFile(credentialsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return gTranslate.NewV3Client(gTranslate.V3Config{
+		Credentials: credentials,
+		ProjectID:   os.Getenv("GTRANSLATE_PROJECT_ID"),
+		Location:    os.Getenv("GTRANSLATE_LOCATION"),
+		GlossaryID:  os.Getenv("GTRANSLATE_GLOSSARY"),
+		Model:       os.Getenv("GTRANSLATE_MODEL"),
+		RateLimit:   10,
+		Burst:       5,
+	}, http.DefaultClient)
+}
+
+// newOfflineTranslater loads a TSV or StarDict dictionary set by
+// OFFLINE_DICTIONARY for the language pair in OFFLINE_DICTIONARY_LANGS
+// (e.g. "en-ru"). It returns nil when no dictionary is configured.
+func newOfflineTranslater() (gTranslate.IClient, error) {
+	path := os.Getenv("OFFLINE_DICTIONARY")
+	if path == "" {
+		return nil, nil
+	}
+
+	source, target, ok := strings.Cut(os.Getenv("OFFLINE_DICTIONARY_LANGS"), "-")
+	if !ok {
+		return nil, errors.New("OFFLINE_DICTIONARY_LANGS must be set as source-target, e.g. en-ru")
+	}
+
+	client := offlineTranslate.NewClient()
+	load := client.LoadTSV
+	if strings.HasSuffix(path, ".ifo") {
+		load = client.LoadStarDict
+	}
+	if err := load(path, source, target); err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// dictionaryLanguages are the comma separated languages in DICTIONARY_LANGS,
+// by default the languages the bot translates between. Words of other
+// languages in the dictionary dump are not loaded.
+func dictionaryLanguages() []string {
+	value := os.Getenv("DICTIONARY_LANGS")
+	if value == "" {
+		return []string{"en", "ru"}
+	}
+
+	var languages []string
+	for _, lang := range strings.Split(value, ",") {
+		if lang = strings.TrimSpace(lang); lang != "" {
+			languages = append(languages, lang)
+		}
+	}
+	return languages
+}
+
+// newSpeechProvider uses the speech server at TTS_URL or the command line
+// in TTS_COMMAND (e.g. tts.DefaultCommand), caching audio in TTS_CACHE_DIR.
+// It returns nil when neither is configured.
+func newSpeechProvider() (tts.Provider, error) {
+	var provider tts.Provider
+	var err error
+	switch {
+	case os.Getenv("TTS_URL") != "":
+		provider, err = tts.NewHTTPProvider(os.Getenv("TTS_URL"), http.DefaultClient)
+	case os.Getenv("TTS_COMMAND") != "":
+		provider, err = tts.NewCommandProvider(os.Getenv("TTS_COMMAND"))
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	dir := os.Getenv("TTS_CACHE_DIR")
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "english-helper-tts")
+	}
+
+	return tts.NewCache(provider, dir)
+}
+
+// newTranscriber uses the whisper.cpp style server at STT_URL or the command
+// line in STT_COMMAND (e.g. stt.DefaultCommand). It returns nil when neither
+// is configured.
+func newTranscriber() (stt.Provider, error) {
+	switch {
+	case os.Getenv("STT_URL") != "":
+		return stt.NewHTTPProvider(os.Getenv("STT_URL"), http.DefaultClient)
+	case os.Getenv("STT_COMMAND") != "":
+		return stt.NewCommandProvider(os.Getenv("STT_COMMAND"))
+	}
+	return nil, nil
+}
+
+// newRecognizer uses the recognition server at OCR_URL or the command line
+// in OCR_COMMAND (e.g. ocr.DefaultCommand). It returns nil when neither is
+// configured.
+func newRecognizer() (ocr.Provider, error) {
+	switch {
+	case os.Getenv("OCR_URL") != "":
+		return ocr.NewHTTPProvider(os.Getenv("OCR_URL"), http.DefaultClient)
+	case os.Getenv("OCR_COMMAND") != "":
+		return ocr.NewCommandProvider(os.Getenv("OCR_COMMAND"))
+	}
+	return nil, nil
+}
+
+// newRepository connects to the database set by DB_DRIVER: "sqlite" or
+// "postgres" with the connection string in DB_DSN, or "mongo" (the default)
+// at MONGO_DB_URI. Data is kept in memory only with DB_DRIVER=memory, so a
+// lost setting fails at startup instead of losing data on restarts.
+func newRepository() (db.IRepository, func() error, error) {
+	log := logger.GetLogger()
+
+	switch driver := os.Getenv("DB_DRIVER"); {
+	case driver == db.DriverSQLite || driver == db.DriverPostgres:
+		conn, err := db.OpenSQL(driver, os.Getenv("DB_DSN"))
+		if err != nil {
+			return nil, nil, err
+		}
+		repo, err := db.NewSQLRepo(conn, driver)
+		if err != nil {
+			conn.Close()
+			return nil, nil, err
+		}
+		return repo, conn.Close, nil
+	case driver == "memory":
+		log.Warn("Keeping data in memory, it is lost on restart")
+		return db.NewMemoryRepo(), func() error { return nil }, nil
+	case driver != "" && driver != "mongo":
+		return nil, nil, fmt.Errorf("unknown DB_DRIVER %q, use mongo, sqlite, postgres or memory", driver)
+	case os.Getenv("MONGO_DB_URI") == "":
+		return nil, nil, errors.New("MONGO_DB_URI is not set, set DB_DRIVER to use another database")
+	}
+
+	client, err := db.InitMongoConnection()
+	if err != nil {
+		return nil, nil, err
+	}
+	if client == nil {
+		return nil, nil, errors.New("Mongo connection is nil, failed creating mongo connection")
+	}
+
+	closeFn := func() error {
+		return client.Disconnect(context.TODO())
+	}
+
+	database := client.Database("bot")
+	if err := db.MigrateMongo(context.TODO(), database); err != nil {
+		closeFn()
+		return nil, nil, err
+	}
+
+	return db.NewMongoRepo(database), closeFn, nil
+}
+
+// setMessageRetention makes messages expire after MESSAGE_RETENTION, a
+// duration like 720h. Messages are kept forever if it is not set.
+func setMessageRetention(repo db.IRepository) error {
+	value := os.Getenv("MESSAGE_RETENTION")
+	if value == "" {
+		return repo.SetMessageRetention(0)
+	}
+
+	ttl, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("parsing MESSAGE_RETENTION: %w", err)
+	}
+	return repo.SetMessageRetention(ttl)
+}
@@ -0,0 +1,98 @@
+// Package cmdrunner runs local command line tools used as service backends,
+// e.g. speech synthesizers or recognizers.
+package cmdrunner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+var (
+	ErrEmptyCommand = errors.New("Command cannot be empty")
+	// ErrOptionValue is returned when a value would turn an argument into an
+	// option of the command, e.g. text "-w/tmp/x".
+	ErrOptionValue = errors.New("Value cannot start an argument with '-'")
+)
+
+// maxStderr limits how much of the command's stderr gets into errors.
+const maxStderr = 512
+
+// Command is a command line with {placeholders} in its arguments, e.g.
+// espeak-ng -v {lang} --stdout -- {text}.
+type Command struct {
+	Name string
+	Args []string
+}
+
+// Parse splits a command line by spaces. Quoting is not supported, so
+// placeholders should be used for arguments with spaces.
+func Parse(line string) (Command, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return Command{}, ErrEmptyCommand
+	}
+	return Command{Name: fields[0], Args: fields[1:]}, nil
+}
+
+// Expand replaces {key} placeholders in the arguments with values of vars.
+// Values are never split or expanded again, so they are safe to pass without
+// quoting. An argument which starts with '-' only because of a value is
+// refused, unless it follows "--", which ends the options of most tools.
+func (c Command) Expand(vars map[string]string) ([]string, error) {
+	var pairs []string
+	for key, value := range vars {
+		pairs = append(pairs, "{"+key+"}", value)
+	}
+	replacer := strings.NewReplacer(pairs...)
+
+	options := true
+	args := make([]string, len(c.Args))
+	for i, arg := range c.Args {
+		args[i] = replacer.Replace(arg)
+		if options && strings.HasPrefix(args[i], "-") && !strings.HasPrefix(arg, "-") {
+			return nil, ErrOptionValue
+		}
+		if arg == "--" {
+			options = false
+		}
+	}
+	return args, nil
+}
+
+// Run runs the command with the placeholders replaced by vars, passing stdin
+// if it is not nil, and returns its stdout.
+func (c Command) Run(ctx context.Context, vars map[string]string, stdin io.Reader) ([]byte, error) {
+	if c.Name == "" {
+		return nil, ErrEmptyCommand
+	}
+
+	args, err := c.Expand(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, c.Name, args...)
+	cmd.Stdin = stdin
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if len(msg) > maxStderr {
+			msg = msg[:maxStderr]
+		}
+		if msg != "" {
+			return nil, fmt.Errorf("%v: %w: %v", c.Name, err, msg)
+		}
+		return nil, fmt.Errorf("%v: %w", c.Name, err)
+	}
+
+	return stdout.Bytes(), nil
+}
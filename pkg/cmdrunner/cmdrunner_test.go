@@ -0,0 +1,122 @@
+package cmdrunner
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		line    string
+		want    Command
+		wantErr error
+	}{
+		{
+			name: "Command with arguments",
+			line: "espeak-ng  -v {lang} --stdout {text}",
+			want: Command{Name: "espeak-ng", Args: []string{"-v", "{lang}", "--stdout", "{text}"}},
+		},
+		{
+			name:    "Empty line",
+			line:    "  ",
+			wantErr: ErrEmptyCommand,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.line)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCommandExpand(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		args    []string
+		vars    map[string]string
+		want    []string
+		wantErr error
+	}{
+		{
+			name: "Values are not expanded again",
+			args: []string{"--lang={lang}", "{text}", "-q"},
+			vars: map[string]string{"lang": "en", "text": "red car {lang}"},
+			want: []string{"--lang=en", "red car {lang}", "-q"},
+		},
+		{
+			name:    "Value starting an option",
+			args:    []string{"-v", "{lang}", "{text}"},
+			vars:    map[string]string{"lang": "en", "text": "-w/tmp/x"},
+			wantErr: ErrOptionValue,
+		},
+		{
+			name: "Value inside an option",
+			args: []string{"--text={text}"},
+			vars: map[string]string{"text": "-w/tmp/x"},
+			want: []string{"--text=-w/tmp/x"},
+		},
+		{
+			name: "Value after the end of options",
+			args: []string{"--stdout", "--", "{text}"},
+			vars: map[string]string{"text": "-w/tmp/x"},
+			want: []string{"--stdout", "--", "-w/tmp/x"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Command{Name: "tts", Args: tt.args}.Expand(tt.vars)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCommandRun(t *testing.T) {
+
+	t.Run("Stdout is returned", func(t *testing.T) {
+		cmd := Command{Name: "echo", Args: []string{"-n", "{text}"}}
+
+		out, err := cmd.Run(context.Background(), map[string]string{"text": "red car"}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "red car", string(out))
+	})
+
+	t.Run("Stdin is passed", func(t *testing.T) {
+		cmd := Command{Name: "cat"}
+
+		out, err := cmd.Run(context.Background(), nil, strings.NewReader("audio"))
+		require.NoError(t, err)
+		assert.Equal(t, "audio", string(out))
+	})
+
+	t.Run("Stderr gets into error", func(t *testing.T) {
+		cmd := Command{Name: "sh", Args: []string{"-c", "echo broken >&2; exit 3"}}
+
+		_, err := cmd.Run(context.Background(), nil, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "broken")
+	})
+
+	t.Run("Value starting an option", func(t *testing.T) {
+		cmd := Command{Name: "echo", Args: []string{"{text}"}}
+
+		_, err := cmd.Run(context.Background(), map[string]string{"text": "-e"}, nil)
+		assert.Equal(t, ErrOptionValue, err)
+	})
+
+	t.Run("Empty command", func(t *testing.T) {
+		_, err := Command{}.Run(context.Background(), nil, nil)
+		assert.Equal(t, ErrEmptyCommand, err)
+	})
+}
@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Large dataset for benchmarks: benchUsers users with benchPerUser
+// translations each.
+const (
+	benchUsers   = 100
+	benchPerUser = 1000
+)
+
+func seedTranslations(b *testing.B, repo IRepository) {
+	for user := 1; user <= benchUsers; user++ {
+		for i := 0; i < benchPerUser; i++ {
+			require.NoError(b, repo.CreateTranslation(&Translation{
+				UserID:     uint(user),
+				ChatID:     int64(user),
+				SourceText: fmt.Sprintf("word %d", i),
+				TargetText: fmt.Sprintf("слово %d", i),
+				Source:     "en",
+				Target:     "ru",
+			}))
+		}
+	}
+}
+
+func benchmarkRandomTranslation(b *testing.B, repo IRepository) {
+	seedTranslations(b, repo)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := repo.GetRandomTranslation(uint(i%benchUsers + 1)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMemoryRepoGetRandomTranslation(b *testing.B) {
+	benchmarkRandomTranslation(b, NewMemoryRepo())
+}
+
+func BenchmarkSQLiteRepoGetRandomTranslation(b *testing.B) {
+	// Durability is not needed to seed a throwaway database
+	dsn := filepath.Join(b.TempDir(), "bench.db") + "?_pragma=synchronous(OFF)&_pragma=journal_mode(MEMORY)"
+	conn, err := OpenSQL(DriverSQLite, dsn)
+	require.NoError(b, err)
+	defer conn.Close()
+
+	repo, err := NewSQLRepo(conn, DriverSQLite)
+	require.NoError(b, err)
+
+	benchmarkRandomTranslation(b, repo)
+}
+
+func BenchmarkMongoRepoGetRandomTranslation(b *testing.B) {
+	uri := os.Getenv("MONGO_TEST_URI")
+	if uri == "" {
+		b.Skip("MONGO_TEST_URI is not set")
+	}
+
+	ctx := context.Background()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	require.NoError(b, err)
+	defer client.Disconnect(ctx)
+
+	database := client.Database(fmt.Sprintf("bot_bench_%d", time.Now().UnixNano()))
+	defer database.Drop(ctx)
+	require.NoError(b, MigrateMongo(ctx, database))
+
+	benchmarkRandomTranslation(b, NewMongoRepo(database))
+}
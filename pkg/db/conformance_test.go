@@ -0,0 +1,495 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// testRepository checks the behaviour every IRepository implementation must
+// share. newRepo must return an empty repository.
+func testRepository(t *testing.T, newRepo func(t *testing.T) IRepository) {
+
+	t.Run("Messages", func(t *testing.T) {
+		repo := newRepo(t)
+
+		require.NoError(t, repo.CreateMessage(&Message{UserID: 1, ChatID: -100, Text: "car"}))
+		require.NoError(t, repo.CreateMessage(&Message{UserID: 1, ChatID: -100, Text: "машина", BotMessage: true}))
+		require.NoError(t, repo.CreateMessage(&Message{UserID: 2, ChatID: 2, Text: "house"}))
+
+		n, err := repo.CountMessages(1)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), n)
+		n, err = repo.CountMessages(3)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+
+		// Disabling retention that was never set is fine
+		require.NoError(t, repo.SetMessageRetention(0))
+		require.NoError(t, repo.SetMessageRetention(24*time.Hour))
+		require.NoError(t, repo.SetMessageRetention(48*time.Hour))
+		require.NoError(t, repo.SetMessageRetention(0))
+	})
+
+	t.Run("Configs", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, err := repo.GetConfig(1)
+		assert.True(t, errors.Is(err, ErrNotFound))
+
+		cfg := Config{UserID: 1, Source: "en", Target: "ru", Mode: "Learn"}
+		require.NoError(t, repo.CreateConfig(&cfg))
+		require.NoError(t, repo.CreateConfig(&Config{UserID: 2, Source: "de", Target: "en", Mode: "Translate"}))
+
+		got, err := repo.GetConfig(1)
+		require.NoError(t, err)
+		assert.Equal(t, cfg, *got)
+
+		// Empty fields are left as they are
+		update := Config{UserID: 1, Mode: "Repeat", TranslationWord: "машина", History: HistoryOff, Version: got.Version}
+		require.NoError(t, repo.UpdateConfig(&update))
+		assert.Equal(t, 2, update.Version)
+		got, err = repo.GetConfig(1)
+		require.NoError(t, err)
+		assert.Equal(t, Config{UserID: 1, Source: "en", Target: "ru", Mode: "Repeat", TranslationWord: "машина", History: HistoryOff, Version: 2}, *got)
+
+		// Updating a missing config is an error
+		assert.Equal(t, ErrNotFound, repo.UpdateConfig(&Config{UserID: 3, Mode: "Repeat", Version: 1}))
+		_, err = repo.GetConfig(3)
+		assert.True(t, errors.Is(err, ErrNotFound))
+	})
+
+	t.Run("Config versions", func(t *testing.T) {
+		repo := newRepo(t)
+
+		first, err := repo.GetOrCreateConfig(&Config{UserID: 1, Source: "en", Target: "ru", Mode: "Learn"})
+		require.NoError(t, err)
+		second, err := repo.GetConfig(1)
+		require.NoError(t, err)
+
+		first.Mode = "Translate"
+		require.NoError(t, repo.UpdateConfig(first))
+
+		// The second copy is outdated and must not overwrite the first update
+		second.Source, second.Target = second.Target, second.Source
+		assert.Equal(t, ErrConflict, repo.UpdateConfig(second))
+
+		got, err := repo.GetConfig(1)
+		require.NoError(t, err)
+		assert.Equal(t, Config{UserID: 1, Source: "en", Target: "ru", Mode: "Translate", Version: 2}, *got)
+
+		// A fresh copy can be saved
+		got.Source, got.Target = got.Target, got.Source
+		require.NoError(t, repo.UpdateConfig(got))
+		assert.Equal(t, 3, got.Version)
+	})
+
+	t.Run("Get or create config", func(t *testing.T) {
+		repo := newRepo(t)
+		defaults := Config{UserID: 1, Source: "en", Target: "ru", Mode: "Learn"}
+
+		cfg, err := repo.GetOrCreateConfig(&defaults)
+		require.NoError(t, err)
+		assert.Equal(t, Config{UserID: 1, Source: "en", Target: "ru", Mode: "Learn", Version: 1}, *cfg)
+		assert.Equal(t, 0, defaults.Version, "defaults must not be changed")
+
+		// An existing config is returned as is
+		cfg.Mode = "Translate"
+		require.NoError(t, repo.UpdateConfig(cfg))
+		cfg, err = repo.GetOrCreateConfig(&defaults)
+		require.NoError(t, err)
+		assert.Equal(t, "Translate", cfg.Mode)
+
+		// Concurrent first messages of a user create a single config
+		var wg sync.WaitGroup
+		errs := make(chan error, 10)
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := repo.GetOrCreateConfig(&Config{UserID: 2, Source: "de", Target: "en", Mode: "Learn"})
+				errs <- err
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			require.NoError(t, err)
+		}
+
+		assert.Equal(t, ErrConflict, repo.CreateConfig(&Config{UserID: 2, Source: "de", Target: "en", Mode: "Learn"}))
+	})
+
+	t.Run("Chat configs", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, err := repo.GetChatConfig(-100)
+		assert.True(t, errors.Is(err, ErrNotFound))
+
+		cfg := ChatConfig{ChatID: -100, Source: "en", Target: "ru", Mode: "Learn"}
+		require.NoError(t, repo.CreateChatConfig(&cfg))
+
+		got, err := repo.GetChatConfig(-100)
+		require.NoError(t, err)
+		assert.Equal(t, cfg, *got)
+
+		require.NoError(t, repo.UpdateChatConfig(&ChatConfig{ChatID: -100, Source: "ru", Target: "en"}))
+		got, err = repo.GetChatConfig(-100)
+		require.NoError(t, err)
+		assert.Equal(t, ChatConfig{ChatID: -100, Source: "ru", Target: "en", Mode: "Learn"}, *got)
+	})
+
+	t.Run("Random translations", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, err := repo.GetRandomTranslation(1)
+		assert.True(t, errors.Is(err, ErrNotFound))
+		_, err = repo.GetRandomChatTranslation(-100)
+		assert.True(t, errors.Is(err, ErrNotFound))
+
+		private := Translation{UserID: 1, ChatID: 1, SourceText: "car", TargetText: "машина", Source: "en", Target: "ru"}
+		other := Translation{UserID: 1, ChatID: 1, SourceText: "cat", TargetText: "кошка", Source: "en", Target: "ru"}
+		group := Translation{UserID: 2, ChatID: -100, SourceText: "dog", TargetText: "собака", Source: "en", Target: "ru"}
+		require.NoError(t, repo.CreateTranslation(&private))
+		require.NoError(t, repo.CreateTranslation(&other))
+		require.NoError(t, repo.CreateTranslation(&group))
+
+		_, err = repo.GetRandomTranslation(3)
+		assert.True(t, errors.Is(err, ErrNotFound))
+
+		for i := 0; i < 5; i++ {
+			got, err := repo.GetRandomTranslation(1)
+			require.NoError(t, err)
+			assert.Contains(t, []Translation{private, other}, *got)
+
+			got, err = repo.GetRandomChatTranslation(-100)
+			require.NoError(t, err)
+			assert.Equal(t, group, *got)
+		}
+	})
+
+	t.Run("Translations of user", func(t *testing.T) {
+		repo := newRepo(t)
+
+		translations, err := repo.GetTranslations(1)
+		require.NoError(t, err)
+		assert.Empty(t, translations)
+
+		want := []Translation{
+			{UserID: 1, ChatID: 1, SourceText: "car", TargetText: "машина", Source: "en", Target: "ru"},
+			{UserID: 1, ChatID: -100, SourceText: "house", TargetText: "дом", Source: "en", Target: "ru"},
+			{UserID: 1, ChatID: 1, SourceText: "Haus", TargetText: "house", Source: "de", Target: "en"},
+		}
+		for i := range want {
+			require.NoError(t, repo.CreateTranslation(&want[i]))
+			require.NoError(t, repo.CreateTranslation(&Translation{UserID: 2, ChatID: 2, SourceText: "cat", TargetText: "кот", Source: "en", Target: "ru"}))
+		}
+
+		translations, err = repo.GetTranslations(1)
+		require.NoError(t, err)
+		assert.Equal(t, want, translations)
+
+		imported := []Translation{
+			{UserID: 1, ChatID: 1, SourceText: "tree", TargetText: "дерево", Source: "en", Target: "ru"},
+			{UserID: 1, ChatID: 1, SourceText: "cat", TargetText: "кот", Source: "en", Target: "ru"},
+		}
+		require.NoError(t, repo.CreateTranslations(imported))
+		require.NoError(t, repo.CreateTranslations(nil))
+
+		translations, err = repo.GetTranslations(1)
+		require.NoError(t, err)
+		assert.Equal(t, append(want, imported...), translations)
+		trnsl, err := repo.GetRandomChatTranslation(1)
+		require.NoError(t, err)
+		assert.Equal(t, uint(1), trnsl.UserID)
+	})
+
+	t.Run("Glossary", func(t *testing.T) {
+		repo := newRepo(t)
+
+		entries, err := repo.GetGlossary(1)
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+
+		require.NoError(t, repo.SaveGlossaryEntry(&GlossaryEntry{UserID: 1, Source: "en", Target: "ru", Term: "pull request", Translation: "пулл реквест"}))
+		require.NoError(t, repo.SaveGlossaryEntry(&GlossaryEntry{UserID: 1, Source: "en", Target: "ru", Term: "pull request", Translation: "запрос на слияние"}))
+		require.NoError(t, repo.SaveGlossaryEntry(&GlossaryEntry{UserID: 1, Source: "en", Target: "de", Term: "pull request", Translation: "Pull-Request"}))
+		require.NoError(t, repo.SaveGlossaryEntry(&GlossaryEntry{UserID: 2, Source: "en", Target: "ru", Term: "branch", Translation: "ветка"}))
+
+		entries, err = repo.GetGlossary(1)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []GlossaryEntry{
+			{UserID: 1, Source: "en", Target: "ru", Term: "pull request", Translation: "запрос на слияние"},
+			{UserID: 1, Source: "en", Target: "de", Term: "pull request", Translation: "Pull-Request"},
+		}, entries)
+
+		deleted, err := repo.DeleteGlossaryEntry(1, "pull request")
+		require.NoError(t, err)
+		assert.True(t, deleted)
+
+		deleted, err = repo.DeleteGlossaryEntry(1, "pull request")
+		require.NoError(t, err)
+		assert.False(t, deleted)
+
+		entries, err = repo.GetGlossary(2)
+		require.NoError(t, err)
+		assert.Len(t, entries, 1)
+	})
+
+	t.Run("Challenges", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, err := repo.GetChallenge(-100)
+		assert.True(t, errors.Is(err, ErrNotFound))
+
+		require.NoError(t, repo.SaveChallenge(&Challenge{ChatID: -100, MessageID: 1, Word: "car", Answer: "машина"}))
+		next := Challenge{ChatID: -100, MessageID: 2, Word: "dog", Answer: "собака"}
+		require.NoError(t, repo.SaveChallenge(&next))
+
+		got, err := repo.GetChallenge(-100)
+		require.NoError(t, err)
+		assert.Equal(t, next, *got)
+
+		// Only the active challenge can be deleted, and only once
+		deleted, err := repo.DeleteChallenge(-100, 1)
+		require.NoError(t, err)
+		assert.False(t, deleted)
+
+		deleted, err = repo.DeleteChallenge(-100, 2)
+		require.NoError(t, err)
+		assert.True(t, deleted)
+
+		deleted, err = repo.DeleteChallenge(-100, 2)
+		require.NoError(t, err)
+		assert.False(t, deleted)
+
+		_, err = repo.GetChallenge(-100)
+		assert.True(t, errors.Is(err, ErrNotFound))
+	})
+
+	t.Run("Delete user data", func(t *testing.T) {
+		repo := newRepo(t)
+
+		for _, userid := range []uint{1, 2} {
+			require.NoError(t, repo.CreateMessage(&Message{UserID: userid, ChatID: -100, Text: "car"}))
+			require.NoError(t, repo.CreateTranslation(&Translation{UserID: userid, ChatID: -100, SourceText: "car", TargetText: "машина", Source: "en", Target: "ru"}))
+			require.NoError(t, repo.CreateConfig(&Config{UserID: userid, Source: "en", Target: "ru", Mode: "Learn", History: HistoryOff}))
+			require.NoError(t, repo.SaveGlossaryEntry(&GlossaryEntry{UserID: userid, Source: "en", Target: "ru", Term: "car", Translation: "авто"}))
+			require.NoError(t, repo.AddScore(&Score{ChatID: -100, UserID: userid, Username: "user", Week: "2023-W07", Points: 1}))
+		}
+
+		require.NoError(t, repo.DeleteUserData(1))
+		// Deleting twice is fine
+		require.NoError(t, repo.DeleteUserData(1))
+
+		n, err := repo.CountMessages(1)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+		_, err = repo.GetRandomTranslation(1)
+		assert.True(t, errors.Is(err, ErrNotFound))
+		_, err = repo.GetConfig(1)
+		assert.True(t, errors.Is(err, ErrNotFound))
+		glossary, err := repo.GetGlossary(1)
+		require.NoError(t, err)
+		assert.Empty(t, glossary)
+
+		// Data of other users is kept
+		n, err = repo.CountMessages(2)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+		trnsl, err := repo.GetRandomChatTranslation(-100)
+		require.NoError(t, err)
+		assert.Equal(t, uint(2), trnsl.UserID)
+		cfg, err := repo.GetConfig(2)
+		require.NoError(t, err)
+		assert.Equal(t, HistoryOff, cfg.History)
+		scores, err := repo.GetLeaderboard(-100, "", 10)
+		require.NoError(t, err)
+		require.Len(t, scores, 1)
+		assert.Equal(t, uint(2), scores[0].UserID)
+	})
+
+	t.Run("Leaderboard", func(t *testing.T) {
+		repo := newRepo(t)
+
+		scores, err := repo.GetLeaderboard(-100, "2023-W07", 10)
+		require.NoError(t, err)
+		assert.Empty(t, scores)
+
+		for _, score := range []Score{
+			{ChatID: -100, UserID: 1, Username: "alice", Week: "2023-W06", Points: 5},
+			{ChatID: -100, UserID: 1, Username: "alice", Week: "2023-W07", Points: 1},
+			{ChatID: -100, UserID: 2, Username: "bob", Week: "2023-W07", Points: 1},
+			{ChatID: -100, UserID: 2, Username: "bobby", Week: "2023-W07", Points: 1},
+			{ChatID: -100, UserID: 3, Username: "carol", Week: "2023-W07", Points: 1},
+			{ChatID: -200, UserID: 4, Username: "dave", Week: "2023-W07", Points: 10},
+		} {
+			require.NoError(t, repo.AddScore(&score))
+		}
+
+		scores, err = repo.GetLeaderboard(-100, "2023-W07", 2)
+		require.NoError(t, err)
+		assert.Equal(t, []Score{
+			{ChatID: -100, UserID: 2, Username: "bobby", Week: "2023-W07", Points: 2},
+			{ChatID: -100, UserID: 1, Username: "alice", Week: "2023-W07", Points: 1},
+		}, scores)
+
+		scores, err = repo.GetLeaderboard(-100, "", 10)
+		require.NoError(t, err)
+		assert.Equal(t, []Score{
+			{ChatID: -100, UserID: 1, Username: "alice", Points: 6},
+			{ChatID: -100, UserID: 2, Username: "bobby", Points: 2},
+			{ChatID: -100, UserID: 3, Username: "carol", Points: 1},
+		}, scores)
+	})
+}
+
+// testRetention checks that expired messages are deleted when new ones are
+// created. Mongo deletes them in the background, so it is not tested here.
+func testRetention(t *testing.T, repo IRepository) {
+	require.NoError(t, repo.CreateMessage(&Message{UserID: 1, ChatID: 1, Text: "old", CreatedAt: time.Now().Add(-2 * time.Hour)}))
+	require.NoError(t, repo.CreateMessage(&Message{UserID: 1, ChatID: 1, Text: "recent"}))
+
+	require.NoError(t, repo.SetMessageRetention(time.Hour))
+	n, err := repo.CountMessages(1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	require.NoError(t, repo.CreateMessage(&Message{UserID: 1, ChatID: 1, Text: "stale", CreatedAt: time.Now().Add(-3 * time.Hour)}))
+	require.NoError(t, repo.CreateMessage(&Message{UserID: 1, ChatID: 1, Text: "new"}))
+	n, err = repo.CountMessages(1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+}
+
+func TestMemoryRepoRetention(t *testing.T) {
+	testRetention(t, NewMemoryRepo())
+}
+
+func TestSQLiteRepoRetention(t *testing.T) {
+	conn, err := OpenSQL(DriverSQLite, filepath.Join(t.TempDir(), "bot.db"))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	repo, err := NewSQLRepo(conn, DriverSQLite)
+	require.NoError(t, err)
+	testRetention(t, repo)
+}
+
+func TestMemoryRepo(t *testing.T) {
+	testRepository(t, func(t *testing.T) IRepository {
+		return NewMemoryRepo()
+	})
+}
+
+// TestMongoRepo runs against the server at MONGO_TEST_URI, every subtest in
+// a database of its own which is dropped afterwards.
+func TestMongoRepo(t *testing.T) {
+	uri := os.Getenv("MONGO_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGO_TEST_URI is not set")
+	}
+
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(uri))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		client.Disconnect(context.Background())
+	})
+
+	testRepository(t, func(t *testing.T) IRepository {
+		database := client.Database(fmt.Sprintf("bot_test_%d", time.Now().UnixNano()))
+		t.Cleanup(func() {
+			database.Drop(context.Background())
+		})
+		require.NoError(t, MigrateMongo(context.Background(), database))
+		return NewMongoRepo(database)
+	})
+}
+
+func TestSQLiteRepo(t *testing.T) {
+	testRepository(t, func(t *testing.T) IRepository {
+		conn, err := OpenSQL(DriverSQLite, filepath.Join(t.TempDir(), "bot.db"))
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			conn.Close()
+		})
+
+		repo, err := NewSQLRepo(conn, DriverSQLite)
+		require.NoError(t, err)
+		return repo
+	})
+}
+
+// TestPostgresRepo runs against the server at POSTGRES_TEST_DSN, given as
+// an url, every subtest in a schema of its own which is dropped afterwards.
+func TestPostgresRepo(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN is not set")
+	}
+
+	admin, err := OpenSQL(DriverPostgres, dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		admin.Close()
+	})
+
+	testRepository(t, func(t *testing.T) IRepository {
+		schema := fmt.Sprintf("bot_test_%d", time.Now().UnixNano())
+		_, err := admin.Exec("CREATE SCHEMA " + schema)
+		require.NoError(t, err)
+
+		u, err := url.Parse(dsn)
+		require.NoError(t, err)
+		q := u.Query()
+		q.Set("search_path", schema)
+		u.RawQuery = q.Encode()
+
+		conn, err := OpenSQL(DriverPostgres, u.String())
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			conn.Close()
+			admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+		})
+
+		repo, err := NewSQLRepo(conn, DriverPostgres)
+		require.NoError(t, err)
+		return repo
+	})
+}
+
+func TestSQLRepoMigrations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bot.db")
+
+	conn, err := OpenSQL(DriverSQLite, path)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = NewSQLRepo(conn, DriverSQLite)
+	require.NoError(t, err)
+
+	// Migrations already applied are skipped
+	repo, err := NewSQLRepo(conn, DriverSQLite)
+	require.NoError(t, err)
+
+	var version int
+	require.NoError(t, conn.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&version))
+	assert.Equal(t, sqlMigrations[len(sqlMigrations)-1].version, version)
+
+	require.NoError(t, repo.CreateConfig(&Config{UserID: 1, Source: "en", Target: "ru", Mode: "Learn"}))
+	assert.Equal(t, ErrConflict, repo.CreateConfig(&Config{UserID: 1, Source: "en", Target: "ru", Mode: "Learn"}))
+
+	_, err = NewSQLRepo(conn, "mysql")
+	assert.Equal(t, errUnknownDriver, err)
+}
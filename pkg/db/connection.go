@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"log"
 	"os"
@@ -25,3 +26,27 @@ func InitMongoConnection() (*mongo.Client, error) {
 
 	return client, nil
 }
+
+// OpenSQL connects to SQLite or PostgreSQL. For SQLite dsn is the path to
+// the database file.
+func OpenSQL(driver string, dsn string) (*sql.DB, error) {
+	if driver != DriverSQLite && driver != DriverPostgres {
+		return nil, errUnknownDriver
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if driver == DriverSQLite {
+		// SQLite allows a single writer, waiting for it is better than
+		// failing with "database is locked"
+		db.SetMaxOpenConns(1)
+	}
+	if err := db.PingContext(context.TODO()); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
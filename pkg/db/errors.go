@@ -0,0 +1,25 @@
+package db
+
+import (
+	"errors"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Errors returned by every IRepository implementation, so callers do not
+// depend on the storage.
+var (
+	ErrNotFound = errors.New("Document not found")
+	ErrConflict = errors.New("Document already exists or was changed concurrently")
+)
+
+// mongoError maps errors of the Mongo driver to the errors above.
+func mongoError(err error) error {
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return ErrNotFound
+	case mongo.IsDuplicateKeyError(err):
+		return ErrConflict
+	}
+	return err
+}
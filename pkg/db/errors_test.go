@@ -0,0 +1,46 @@
+package db
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestMongoError(t *testing.T) {
+	other := errors.New("connection refused")
+
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{
+			name: "No documents",
+			err:  mongo.ErrNoDocuments,
+			want: ErrNotFound,
+		},
+		{
+			name: "Duplicate key",
+			err:  mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000, Message: "E11000 duplicate key error"}}},
+			want: ErrConflict,
+		},
+		{
+			name: "Other errors are kept",
+			err:  other,
+			want: other,
+		},
+		{
+			name: "No error",
+			err:  nil,
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, mongoError(tt.err))
+		})
+	}
+}
@@ -0,0 +1,471 @@
+package db
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryRepo keeps everything in memory. It behaves like MongoRepo and is
+// meant for running the bot without a database and for tests.
+type MemoryRepo struct {
+	mu           sync.RWMutex
+	messages     []Message
+	retention    time.Duration
+	translations []Translation
+	// positions of translations by user and by chat
+	byUser      map[uint][]int
+	byChat      map[int64][]int
+	configs     []Config
+	chatConfigs []ChatConfig
+	glossary    []GlossaryEntry
+	challenges  []Challenge
+	scores      []Score
+}
+
+func NewMemoryRepo() IRepository {
+	return &MemoryRepo{
+		byUser: make(map[uint][]int),
+		byChat: make(map[int64][]int),
+	}
+}
+
+func (r *MemoryRepo) CreateMessage(msg *Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if msg.CreatedAt.IsZero() {
+		msg.CreatedAt = time.Now().UTC()
+	}
+	r.expireMessages()
+	r.messages = append(r.messages, *msg)
+
+	return nil
+}
+
+func (r *MemoryRepo) CountMessages(userid uint) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var n int64
+	for _, msg := range r.messages {
+		if msg.UserID == userid {
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+// SetMessageRetention makes CreateMessage drop expired messages.
+func (r *MemoryRepo) SetMessageRetention(ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.retention = ttl
+	r.expireMessages()
+
+	return nil
+}
+
+func (r *MemoryRepo) expireMessages() {
+	if r.retention <= 0 {
+		return
+	}
+
+	expired := time.Now().Add(-r.retention)
+	kept := r.messages[:0]
+	for _, msg := range r.messages {
+		if msg.CreatedAt.After(expired) {
+			kept = append(kept, msg)
+		}
+	}
+	r.messages = kept
+}
+
+func (r *MemoryRepo) CreateTranslation(trnsl *Translation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.byUser[trnsl.UserID] = append(r.byUser[trnsl.UserID], len(r.translations))
+	r.byChat[trnsl.ChatID] = append(r.byChat[trnsl.ChatID], len(r.translations))
+	r.translations = append(r.translations, *trnsl)
+
+	return nil
+}
+
+func (r *MemoryRepo) CreateTranslations(translations []Translation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, trnsl := range translations {
+		r.byUser[trnsl.UserID] = append(r.byUser[trnsl.UserID], len(r.translations))
+		r.byChat[trnsl.ChatID] = append(r.byChat[trnsl.ChatID], len(r.translations))
+		r.translations = append(r.translations, trnsl)
+	}
+
+	return nil
+}
+
+func (r *MemoryRepo) GetRandomTranslation(userid uint) (*Translation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.randomTranslation(r.byUser[userid])
+}
+
+func (r *MemoryRepo) GetTranslations(userid uint) ([]Translation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var translations []Translation
+	for _, i := range r.byUser[userid] {
+		translations = append(translations, r.translations[i])
+	}
+
+	return translations, nil
+}
+
+func (r *MemoryRepo) randomTranslation(positions []int) (*Translation, error) {
+	if len(positions) == 0 {
+		return nil, ErrNotFound
+	}
+
+	trnsl := r.translations[positions[rand.Intn(len(positions))]]
+	return &trnsl, nil
+}
+
+func (r *MemoryRepo) CreateConfig(cfg *Config) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, c := range r.configs {
+		if c.UserID == cfg.UserID {
+			return ErrConflict
+		}
+	}
+	if cfg.Version == 0 {
+		cfg.Version = 1
+	}
+
+	r.configs = append(r.configs, *cfg)
+
+	return nil
+}
+
+func (r *MemoryRepo) GetConfig(userid uint) (*Config, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, cfg := range r.configs {
+		if cfg.UserID == userid {
+			return &cfg, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (r *MemoryRepo) GetOrCreateConfig(defaults *Config) (*Config, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, cfg := range r.configs {
+		if cfg.UserID == defaults.UserID {
+			return &cfg, nil
+		}
+	}
+
+	cfg := Config{
+		UserID:  defaults.UserID,
+		Source:  defaults.Source,
+		Target:  defaults.Target,
+		Mode:    defaults.Mode,
+		Version: 1,
+	}
+	r.configs = append(r.configs, cfg)
+
+	return &cfg, nil
+}
+
+// UpdateConfig sets only non-empty fields, the way $set with omitempty
+// fields does in MongoRepo, if the version of cfg is the latest one.
+func (r *MemoryRepo) UpdateConfig(cfg *Config) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.configs {
+		if r.configs[i].UserID != cfg.UserID {
+			continue
+		}
+		if r.configs[i].Version != cfg.Version {
+			return ErrConflict
+		}
+		mergeConfig(&r.configs[i], cfg)
+		r.configs[i].Version++
+		cfg.Version = r.configs[i].Version
+		return nil
+	}
+
+	return ErrNotFound
+}
+
+func (r *MemoryRepo) CreateChatConfig(cfg *ChatConfig) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, c := range r.chatConfigs {
+		if c.ChatID == cfg.ChatID {
+			return ErrConflict
+		}
+	}
+
+	r.chatConfigs = append(r.chatConfigs, *cfg)
+
+	return nil
+}
+
+func (r *MemoryRepo) GetChatConfig(chatid int64) (*ChatConfig, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, cfg := range r.chatConfigs {
+		if cfg.ChatID == chatid {
+			return &cfg, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (r *MemoryRepo) UpdateChatConfig(cfg *ChatConfig) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.chatConfigs {
+		if r.chatConfigs[i].ChatID == cfg.ChatID {
+			mergeChatConfig(&r.chatConfigs[i], cfg)
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (r *MemoryRepo) SaveGlossaryEntry(entry *GlossaryEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, e := range r.glossary {
+		if e.UserID == entry.UserID && e.Source == entry.Source && e.Target == entry.Target && e.Term == entry.Term {
+			if entry.Translation != "" {
+				r.glossary[i].Translation = entry.Translation
+			}
+			return nil
+		}
+	}
+	r.glossary = append(r.glossary, *entry)
+
+	return nil
+}
+
+func (r *MemoryRepo) GetGlossary(userid uint) ([]GlossaryEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var entries []GlossaryEntry
+	for _, e := range r.glossary {
+		if e.UserID == userid {
+			entries = append(entries, e)
+		}
+	}
+
+	return entries, nil
+}
+
+func (r *MemoryRepo) DeleteGlossaryEntry(userid uint, term string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.glossary[:0]
+	for _, e := range r.glossary {
+		if e.UserID != userid || e.Term != term {
+			kept = append(kept, e)
+		}
+	}
+	deleted := len(kept) < len(r.glossary)
+	r.glossary = kept
+
+	return deleted, nil
+}
+
+func (r *MemoryRepo) GetRandomChatTranslation(chatid int64) (*Translation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.randomTranslation(r.byChat[chatid])
+}
+
+func (r *MemoryRepo) SaveChallenge(challenge *Challenge) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.challenges {
+		if r.challenges[i].ChatID == challenge.ChatID {
+			r.challenges[i] = *challenge
+			return nil
+		}
+	}
+	r.challenges = append(r.challenges, *challenge)
+
+	return nil
+}
+
+func (r *MemoryRepo) GetChallenge(chatid int64) (*Challenge, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, challenge := range r.challenges {
+		if challenge.ChatID == chatid {
+			return &challenge, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (r *MemoryRepo) DeleteChallenge(chatid int64, messageid int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, challenge := range r.challenges {
+		if challenge.ChatID == chatid && challenge.MessageID == messageid {
+			r.challenges = append(r.challenges[:i], r.challenges[i+1:]...)
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (r *MemoryRepo) AddScore(score *Score) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, s := range r.scores {
+		if s.ChatID == score.ChatID && s.UserID == score.UserID && s.Week == score.Week {
+			r.scores[i].Points += score.Points
+			r.scores[i].Username = score.Username
+			return nil
+		}
+	}
+	r.scores = append(r.scores, *score)
+
+	return nil
+}
+
+func (r *MemoryRepo) GetLeaderboard(chatid int64, week string, limit int) ([]Score, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var scores []Score
+	for _, s := range r.scores {
+		if s.ChatID == chatid && (week == "" || s.Week == week) {
+			scores = append(scores, s)
+		}
+	}
+
+	return rankScores(scores, week, limit), nil
+}
+
+func (r *MemoryRepo) DeleteUserData(userid uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.messages = without(r.messages, func(msg Message) bool { return msg.UserID == userid })
+	r.translations = without(r.translations, func(t Translation) bool { return t.UserID == userid })
+	r.configs = without(r.configs, func(cfg Config) bool { return cfg.UserID == userid })
+	r.glossary = without(r.glossary, func(e GlossaryEntry) bool { return e.UserID == userid })
+	r.scores = without(r.scores, func(s Score) bool { return s.UserID == userid })
+
+	// Positions of the remaining translations have changed
+	r.byUser = make(map[uint][]int)
+	r.byChat = make(map[int64][]int)
+	for i, t := range r.translations {
+		r.byUser[t.UserID] = append(r.byUser[t.UserID], i)
+		r.byChat[t.ChatID] = append(r.byChat[t.ChatID], i)
+	}
+
+	return nil
+}
+
+// without filters out the items matching remove in place.
+func without[T any](items []T, remove func(T) bool) []T {
+	kept := items[:0]
+	for _, item := range items {
+		if !remove(item) {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+// rankScores sums points of every user and sorts users by them. The latest
+// username of a user is shown.
+func rankScores(scores []Score, week string, limit int) []Score {
+	var ranked []Score
+	byUser := make(map[uint]int)
+	for _, s := range scores {
+		i, ok := byUser[s.UserID]
+		if !ok {
+			i = len(ranked)
+			byUser[s.UserID] = i
+			ranked = append(ranked, Score{ChatID: s.ChatID, UserID: s.UserID, Week: week})
+		}
+		ranked[i].Username = s.Username
+		ranked[i].Points += s.Points
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Points != ranked[j].Points {
+			return ranked[i].Points > ranked[j].Points
+		}
+		return ranked[i].UserID < ranked[j].UserID
+	})
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+
+	return ranked
+}
+
+func mergeConfig(dst *Config, src *Config) {
+	if src.Source != "" {
+		dst.Source = src.Source
+	}
+	if src.Target != "" {
+		dst.Target = src.Target
+	}
+	if src.Mode != "" {
+		dst.Mode = src.Mode
+	}
+	if src.TranslationWord != "" {
+		dst.TranslationWord = src.TranslationWord
+	}
+	if src.History != "" {
+		dst.History = src.History
+	}
+}
+
+func mergeChatConfig(dst *ChatConfig, src *ChatConfig) {
+	if src.Source != "" {
+		dst.Source = src.Source
+	}
+	if src.Target != "" {
+		dst.Target = src.Target
+	}
+	if src.Mode != "" {
+		dst.Mode = src.Mode
+	}
+}
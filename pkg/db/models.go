@@ -1,15 +1,19 @@
 package db
 
+import "time"
+
 type Message struct {
 	UserID     uint   `bson:"userid,omitempty"`
-	ChatID     uint   `bson:"chatid,omitempty"`
+	ChatID     int64  `bson:"chatid,omitempty"`
 	Text       string `bson:"text,omitempty"`
 	BotMessage bool   `bson:"isbot,omitempty"`
+	// CreatedAt is set by the repository, messages expire by it
+	CreatedAt time.Time `bson:"createdat,omitempty"`
 }
 
 type Translation struct {
 	UserID     uint   `bson:"userid,omitempty"`
-	ChatID     uint   `bson:"chatid,omitempty"`
+	ChatID     int64  `bson:"chatid,omitempty"`
 	SourceText string `bson:"sourcetext,omitempty"`
 	TargetText string `bson:"targettext,omitempty"`
 	Source     string `bson:"source,omitempty"`
@@ -22,4 +26,50 @@ type Config struct {
 	Target          string `bson:"target,omitempty"`
 	Mode            string `bson:"mode,omitempty"`
 	TranslationWord string `bson:"translationWord,omitempty"`
+	// History is HistoryOff if the user has opted out of message logging
+	History string `bson:"history,omitempty"`
+	// Version is increased by every update, an update of an outdated config
+	// fails with ErrConflict.
+	Version int `bson:"version,omitempty"`
+}
+
+type GlossaryEntry struct {
+	UserID      uint   `bson:"userid,omitempty"`
+	Source      string `bson:"source,omitempty"`
+	Target      string `bson:"target,omitempty"`
+	Term        string `bson:"term,omitempty"`
+	Translation string `bson:"translation,omitempty"`
+}
+
+// Values of Config.History. An empty value means HistoryOn, since updates
+// skip empty fields a user turning history back on gets HistoryOn.
+const (
+	HistoryOn  = "on"
+	HistoryOff = "off"
+)
+
+// ChatConfig holds settings of a group chat, shared by all its members.
+type ChatConfig struct {
+	ChatID int64  `bson:"chatid,omitempty"`
+	Source string `bson:"source,omitempty"`
+	Target string `bson:"target,omitempty"`
+	Mode   string `bson:"mode,omitempty"`
+}
+
+// Challenge is a word posted to a group chat, waiting for the first correct
+// answer.
+type Challenge struct {
+	ChatID    int64  `bson:"chatid,omitempty"`
+	MessageID int    `bson:"messageid,omitempty"`
+	Word      string `bson:"word,omitempty"`
+	Answer    string `bson:"answer,omitempty"`
+}
+
+// Score is the number of points a user got in a group chat during a week.
+type Score struct {
+	ChatID   int64  `bson:"chatid,omitempty"`
+	UserID   uint   `bson:"userid,omitempty"`
+	Username string `bson:"username,omitempty"`
+	Week     string `bson:"week,omitempty"`
+	Points   int    `bson:"points,omitempty"`
 }
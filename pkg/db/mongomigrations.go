@@ -0,0 +1,183 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/maxik12233/english-helper-telegrambot/pkg/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+const migrationsCollection = "migrations"
+
+// mongoMigration changes indexes or data of the database. Several instances
+// of the bot may start at once, so up must be safe to run more than once.
+type mongoMigration struct {
+	version     int
+	description string
+	up          func(ctx context.Context, db *mongo.Database) error
+}
+
+type appliedMigration struct {
+	Version     int       `bson:"version"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"appliedat"`
+}
+
+// mongoMigrations are applied in order and must never be changed once
+// released, add a new migration instead.
+var mongoMigrations = []mongoMigration{
+	{
+		version:     1,
+		description: "remove duplicate user and chat configs",
+		up: func(ctx context.Context, db *mongo.Database) error {
+			if err := removeDuplicates(ctx, db.Collection("userconfigs"), "userid"); err != nil {
+				return err
+			}
+			return removeDuplicates(ctx, db.Collection("chatconfigs"), "chatid")
+		},
+	},
+	{
+		version:     2,
+		description: "create indexes",
+		up: func(ctx context.Context, db *mongo.Database) error {
+			indexes := map[string][]mongo.IndexModel{
+				"userconfigs": {
+					uniqueIndex(bson.D{{Key: "userid", Value: 1}}),
+				},
+				"chatconfigs": {
+					uniqueIndex(bson.D{{Key: "chatid", Value: 1}}),
+				},
+				"translations": {
+					{Keys: bson.D{{Key: "userid", Value: 1}, {Key: "source", Value: 1}, {Key: "target", Value: 1}}},
+					{Keys: bson.D{{Key: "chatid", Value: 1}}},
+				},
+				"glossary": {
+					uniqueIndex(bson.D{{Key: "userid", Value: 1}, {Key: "source", Value: 1}, {Key: "target", Value: 1}, {Key: "term", Value: 1}}),
+				},
+				"challenges": {
+					uniqueIndex(bson.D{{Key: "chatid", Value: 1}}),
+				},
+				"scores": {
+					uniqueIndex(bson.D{{Key: "chatid", Value: 1}, {Key: "userid", Value: 1}, {Key: "week", Value: 1}}),
+					{Keys: bson.D{{Key: "chatid", Value: 1}, {Key: "week", Value: 1}, {Key: "points", Value: -1}}},
+				},
+				migrationsCollection: {
+					uniqueIndex(bson.D{{Key: "version", Value: 1}}),
+				},
+			}
+			for collection, models := range indexes {
+				if _, err := db.Collection(collection).Indexes().CreateMany(ctx, models); err != nil {
+					return fmt.Errorf("creating indexes of %v: %w", collection, err)
+				}
+			}
+			return nil
+		},
+	},
+	{
+		version:     3,
+		description: "add version to user configs",
+		up: func(ctx context.Context, db *mongo.Database) error {
+			filter := bson.D{{Key: "version", Value: bson.D{{Key: "$exists", Value: false}}}}
+			update := bson.D{{Key: "$set", Value: bson.D{{Key: "version", Value: 1}}}}
+			_, err := db.Collection("userconfigs").UpdateMany(ctx, filter, update)
+			return err
+		},
+	},
+	{
+		version:     4,
+		description: "set creation time of old messages",
+		up: func(ctx context.Context, db *mongo.Database) error {
+			// Messages without it would never expire
+			filter := bson.D{{Key: "createdat", Value: bson.D{{Key: "$exists", Value: false}}}}
+			update := bson.D{{Key: "$set", Value: bson.D{{Key: "createdat", Value: time.Now().UTC()}}}}
+			_, err := db.Collection("messages").UpdateMany(ctx, filter, update)
+			return err
+		},
+	},
+}
+
+func uniqueIndex(keys bson.D) mongo.IndexModel {
+	return mongo.IndexModel{Keys: keys, Options: options.Index().SetUnique(true)}
+}
+
+// MigrateMongo applies migrations not yet recorded in the migrations
+// collection. It must be called before the database is used by MongoRepo.
+func MigrateMongo(ctx context.Context, db *mongo.Database) error {
+	log := logger.GetLogger()
+
+	res, err := db.Collection(migrationsCollection).Find(ctx, bson.D{})
+	if err != nil {
+		return err
+	}
+	var applied []appliedMigration
+	if err := res.All(ctx, &applied); err != nil {
+		return err
+	}
+	done := make(map[int]bool)
+	for _, m := range applied {
+		done[m.Version] = true
+	}
+
+	for _, m := range mongoMigrations {
+		if done[m.version] {
+			continue
+		}
+
+		log.Info("Applying mongo migration", zap.Int("version", m.version), zap.String("description", m.description))
+		if err := m.up(ctx, db); err != nil {
+			return fmt.Errorf("migration %d (%v): %w", m.version, m.description, err)
+		}
+
+		_, err := db.Collection(migrationsCollection).InsertOne(ctx, appliedMigration{
+			Version:     m.version,
+			Description: m.description,
+			AppliedAt:   time.Now().UTC(),
+		})
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			// A duplicate means another instance has applied it at the same time
+			return err
+		}
+	}
+
+	return nil
+}
+
+// removeDuplicates keeps the oldest document for every value of key. It is
+// the one updates went to, since they match documents in natural order.
+func removeDuplicates(ctx context.Context, collection *mongo.Collection, key string) error {
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$" + key},
+			{Key: "ids", Value: bson.D{{Key: "$push", Value: "$_id"}}},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+		{{Key: "$match", Value: bson.D{{Key: "count", Value: bson.D{{Key: "$gt", Value: 1}}}}}},
+	}
+	res, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+
+	var groups []struct {
+		IDs []interface{} `bson:"ids"`
+	}
+	if err := res.All(ctx, &groups); err != nil {
+		return err
+	}
+
+	for _, group := range groups {
+		filter := bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: group.IDs[1:]}}}}
+		if _, err := collection.DeleteMany(ctx, filter); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestMigrateMongo(t *testing.T) {
+	uri := os.Getenv("MONGO_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGO_TEST_URI is not set")
+	}
+
+	ctx := context.Background()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	require.NoError(t, err)
+	defer client.Disconnect(ctx)
+
+	database := client.Database(fmt.Sprintf("bot_test_%d", time.Now().UnixNano()))
+	defer database.Drop(ctx)
+
+	// Duplicates left by concurrent GetOrCreateUserConfig calls
+	configs := database.Collection("userconfigs")
+	_, err = configs.InsertMany(ctx, []interface{}{
+		Config{UserID: 1, Source: "en", Target: "ru", Mode: "Repeat"},
+		Config{UserID: 1, Source: "en", Target: "ru", Mode: "Learn"},
+		Config{UserID: 2, Source: "de", Target: "en", Mode: "Learn"},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, MigrateMongo(ctx, database))
+	// Applied migrations are skipped
+	require.NoError(t, MigrateMongo(ctx, database))
+
+	n, err := configs.CountDocuments(ctx, bson.D{{Key: "userid", Value: 1}})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, n)
+
+	repo := NewMongoRepo(database)
+	cfg, err := repo.GetConfig(1)
+	require.NoError(t, err)
+	assert.Equal(t, "Repeat", cfg.Mode)
+
+	assert.Equal(t, ErrConflict, repo.CreateConfig(&Config{UserID: 2, Source: "en", Target: "ru", Mode: "Learn"}))
+
+	applied, err := database.Collection(migrationsCollection).CountDocuments(ctx, bson.D{})
+	require.NoError(t, err)
+	assert.EqualValues(t, len(mongoMigrations), applied)
+}
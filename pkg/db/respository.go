@@ -2,21 +2,46 @@ package db
 
 import (
 	"context"
-	"math/rand"
+	"errors"
+	"time"
 
 	"github.com/maxik12233/english-helper-telegrambot/pkg/logger"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/zap"
 )
 
 type IRepository interface {
 	CreateMessage(msg *Message) error
+	CountMessages(userid uint) (int64, error)
+	// SetMessageRetention makes messages expire ttl after creation, zero
+	// keeps them forever.
+	SetMessageRetention(ttl time.Duration) error
 	CreateTranslation(trnsl *Translation) error
-	GetRandomTranslation() (*Translation, error)
+	// CreateTranslations saves all translations at once.
+	CreateTranslations(translations []Translation) error
+	GetRandomTranslation(userid uint) (*Translation, error)
+	// GetTranslations returns all translations of the user, oldest first.
+	GetTranslations(userid uint) ([]Translation, error)
 	CreateConfig(cfg *Config) error
 	GetConfig(userid uint) (*Config, error)
+	GetOrCreateConfig(defaults *Config) (*Config, error)
 	UpdateConfig(cfg *Config) error
+	CreateChatConfig(cfg *ChatConfig) error
+	GetChatConfig(chatid int64) (*ChatConfig, error)
+	UpdateChatConfig(cfg *ChatConfig) error
+	SaveGlossaryEntry(entry *GlossaryEntry) error
+	GetGlossary(userid uint) ([]GlossaryEntry, error)
+	DeleteGlossaryEntry(userid uint, term string) (bool, error)
+	GetRandomChatTranslation(chatid int64) (*Translation, error)
+	SaveChallenge(challenge *Challenge) error
+	GetChallenge(chatid int64) (*Challenge, error)
+	DeleteChallenge(chatid int64, messageid int) (bool, error)
+	AddScore(score *Score) error
+	GetLeaderboard(chatid int64, week string, limit int) ([]Score, error)
+	// DeleteUserData removes everything stored about the user.
+	DeleteUserData(userid uint) error
 }
 
 type MongoRepo struct {
@@ -30,49 +55,151 @@ func NewMongoRepo(mongo *mongo.Database) IRepository {
 }
 
 func (r *MongoRepo) CreateMessage(msg *Message) error {
+	if msg.CreatedAt.IsZero() {
+		msg.CreatedAt = time.Now().UTC()
+	}
 
 	_, err := r.mongo.Collection("messages").InsertOne(context.TODO(), msg)
 	if err != nil {
-		return err
+		return mongoError(err)
 	}
 
 	return nil
 }
 
+func (r *MongoRepo) CountMessages(userid uint) (int64, error) {
+
+	n, err := r.mongo.Collection("messages").CountDocuments(context.TODO(), bson.D{{Key: "userid", Value: userid}})
+	if err != nil {
+		return 0, mongoError(err)
+	}
+
+	return n, nil
+}
+
+const messagesTTLIndex = "messages_ttl"
+
+// Codes of Mongo command errors.
+const (
+	codeIndexNotFound        = 27
+	codeIndexOptionsConflict = 85
+)
+
+// SetMessageRetention creates a TTL index on the creation time of messages,
+// so Mongo deletes them in the background.
+func (r *MongoRepo) SetMessageRetention(ttl time.Duration) error {
+	indexes := r.mongo.Collection("messages").Indexes()
+
+	var cmdErr mongo.CommandError
+	if ttl <= 0 {
+		_, err := indexes.DropOne(context.TODO(), messagesTTLIndex)
+		if errors.As(err, &cmdErr) && cmdErr.Code == codeIndexNotFound {
+			return nil
+		}
+		return mongoError(err)
+	}
+
+	seconds := int32(ttl.Seconds())
+	_, err := indexes.CreateOne(context.TODO(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "createdat", Value: 1}},
+		Options: options.Index().SetName(messagesTTLIndex).SetExpireAfterSeconds(seconds),
+	})
+	if errors.As(err, &cmdErr) && cmdErr.Code == codeIndexOptionsConflict {
+		// The index exists with another ttl
+		res := r.mongo.RunCommand(context.TODO(), bson.D{
+			{Key: "collMod", Value: "messages"},
+			{Key: "index", Value: bson.D{
+				{Key: "name", Value: messagesTTLIndex},
+				{Key: "expireAfterSeconds", Value: seconds},
+			}},
+		})
+		return mongoError(res.Err())
+	}
+
+	return mongoError(err)
+}
+
 func (r *MongoRepo) CreateTranslation(trnsl *Translation) error {
 
 	_, err := r.mongo.Collection("translations").InsertOne(context.TODO(), trnsl)
 	if err != nil {
-		return err
+		return mongoError(err)
 	}
 
 	return nil
 }
 
-func (r *MongoRepo) GetRandomTranslation() (*Translation, error) {
-	log := logger.GetLogger()
+func (r *MongoRepo) CreateTranslations(translations []Translation) error {
+	if len(translations) == 0 {
+		return nil
+	}
 
-	res, err := r.mongo.Collection("translations").Find(context.TODO(), bson.D{})
+	docs := make([]interface{}, len(translations))
+	for i := range translations {
+		docs[i] = translations[i]
+	}
+	_, err := r.mongo.Collection("translations").InsertMany(context.TODO(), docs)
 	if err != nil {
-		log.Error("Error while updating user config", zap.Error(err))
-		return nil, err
+		return mongoError(err)
+	}
+
+	return nil
+}
+
+// GetRandomTranslation picks a random translation saved by the user on the
+// server side.
+func (r *MongoRepo) GetRandomTranslation(userid uint) (*Translation, error) {
+	return r.sampleTranslation(bson.D{{Key: "userid", Value: userid}})
+}
+
+func (r *MongoRepo) GetTranslations(userid uint) ([]Translation, error) {
+
+	// Ids grow with insertion time
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	res, err := r.mongo.Collection("translations").Find(context.TODO(), bson.D{{Key: "userid", Value: userid}}, opts)
+	if err != nil {
+		return nil, mongoError(err)
 	}
 
 	var translations []Translation
-	i := rand.Intn(res.RemainingBatchLength())
 	if err = res.All(context.TODO(), &translations); err != nil {
-		log.Error("Error while updating user config", zap.Error(err))
-		return nil, err
+		return nil, mongoError(err)
 	}
 
-	return &translations[i], nil
+	return translations, nil
+}
+
+// sampleTranslation returns a random translation matching the filter.
+func (r *MongoRepo) sampleTranslation(filter bson.D) (*Translation, error) {
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$sample", Value: bson.D{{Key: "size", Value: 1}}}},
+	}
+	res, err := r.mongo.Collection("translations").Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+
+	var translations []Translation
+	if err = res.All(context.TODO(), &translations); err != nil {
+		return nil, mongoError(err)
+	}
+	if len(translations) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return &translations[0], nil
 }
 
 func (r *MongoRepo) CreateConfig(cfg *Config) error {
+	if cfg.Version == 0 {
+		cfg.Version = 1
+	}
 
 	_, err := r.mongo.Collection("userconfigs").InsertOne(context.TODO(), cfg)
 	if err != nil {
-		return err
+		return mongoError(err)
 	}
 
 	return nil
@@ -82,27 +209,288 @@ func (r *MongoRepo) GetConfig(userid uint) (*Config, error) {
 
 	res := r.mongo.Collection("userconfigs").FindOne(context.TODO(), bson.D{{Key: "userid", Value: userid}})
 	if res.Err() != nil {
-		return nil, res.Err()
+		return nil, mongoError(res.Err())
 	}
 
 	var cfg Config
 	err := res.Decode(&cfg)
 	if err != nil {
-		return nil, err
+		return nil, mongoError(err)
 	}
 
 	return &cfg, nil
 }
 
+// GetOrCreateConfig returns the config of defaults.UserID, creating it from
+// defaults if there is none, in a single upsert.
+func (r *MongoRepo) GetOrCreateConfig(defaults *Config) (*Config, error) {
+
+	filter := bson.D{{Key: "userid", Value: defaults.UserID}}
+	update := bson.D{{Key: "$setOnInsert", Value: bson.D{
+		{Key: "source", Value: defaults.Source},
+		{Key: "target", Value: defaults.Target},
+		{Key: "mode", Value: defaults.Mode},
+		{Key: "version", Value: 1},
+	}}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	res := r.mongo.Collection("userconfigs").FindOneAndUpdate(context.TODO(), filter, update, opts)
+	if mongo.IsDuplicateKeyError(res.Err()) {
+		// Concurrent upserts may both try to insert, the loser finds the
+		// winner's config
+		return r.GetConfig(defaults.UserID)
+	}
+	if res.Err() != nil {
+		return nil, mongoError(res.Err())
+	}
+
+	var cfg Config
+	if err := res.Decode(&cfg); err != nil {
+		return nil, mongoError(err)
+	}
+
+	return &cfg, nil
+}
+
+// UpdateConfig saves non-empty fields of cfg if the stored config has the
+// same version and increases the version of both.
 func (r *MongoRepo) UpdateConfig(cfg *Config) error {
 	log := logger.GetLogger()
 
-	update := bson.D{{Key: "$set", Value: cfg}}
+	updated := *cfg
+	updated.Version = cfg.Version + 1
+	update := bson.D{{Key: "$set", Value: updated}}
 
-	_, err := r.mongo.Collection("userconfigs").UpdateOne(context.TODO(), bson.D{{Key: "userid", Value: cfg.UserID}}, update)
+	filter := bson.D{{Key: "userid", Value: cfg.UserID}, {Key: "version", Value: cfg.Version}}
+	res, err := r.mongo.Collection("userconfigs").UpdateOne(context.TODO(), filter, update)
 	if err != nil {
 		log.Error("Error while updating user config", zap.Error(err))
-		return err
+		return mongoError(err)
+	}
+
+	if res.MatchedCount == 0 {
+		n, err := r.mongo.Collection("userconfigs").CountDocuments(context.TODO(), bson.D{{Key: "userid", Value: cfg.UserID}})
+		if err != nil {
+			return mongoError(err)
+		}
+		if n == 0 {
+			return ErrNotFound
+		}
+		return ErrConflict
+	}
+	cfg.Version = updated.Version
+
+	return nil
+}
+
+func (r *MongoRepo) CreateChatConfig(cfg *ChatConfig) error {
+
+	_, err := r.mongo.Collection("chatconfigs").InsertOne(context.TODO(), cfg)
+	if err != nil {
+		return mongoError(err)
+	}
+
+	return nil
+}
+
+func (r *MongoRepo) GetChatConfig(chatid int64) (*ChatConfig, error) {
+
+	res := r.mongo.Collection("chatconfigs").FindOne(context.TODO(), bson.D{{Key: "chatid", Value: chatid}})
+	if res.Err() != nil {
+		return nil, mongoError(res.Err())
+	}
+
+	var cfg ChatConfig
+	err := res.Decode(&cfg)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+
+	return &cfg, nil
+}
+
+func (r *MongoRepo) UpdateChatConfig(cfg *ChatConfig) error {
+	log := logger.GetLogger()
+
+	update := bson.D{{Key: "$set", Value: cfg}}
+
+	_, err := r.mongo.Collection("chatconfigs").UpdateOne(context.TODO(), bson.D{{Key: "chatid", Value: cfg.ChatID}}, update)
+	if err != nil {
+		log.Error("Error while updating chat config", zap.Error(err))
+		return mongoError(err)
+	}
+
+	return nil
+}
+
+// SaveGlossaryEntry creates the entry or replaces the translation of the same
+// term for the same language pair.
+func (r *MongoRepo) SaveGlossaryEntry(entry *GlossaryEntry) error {
+	log := logger.GetLogger()
+
+	filter := bson.D{
+		{Key: "userid", Value: entry.UserID},
+		{Key: "source", Value: entry.Source},
+		{Key: "target", Value: entry.Target},
+		{Key: "term", Value: entry.Term},
+	}
+	update := bson.D{{Key: "$set", Value: entry}}
+
+	_, err := r.mongo.Collection("glossary").UpdateOne(context.TODO(), filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		log.Error("Error while saving glossary entry", zap.Error(err))
+		return mongoError(err)
+	}
+
+	return nil
+}
+
+func (r *MongoRepo) GetGlossary(userid uint) ([]GlossaryEntry, error) {
+
+	res, err := r.mongo.Collection("glossary").Find(context.TODO(), bson.D{{Key: "userid", Value: userid}})
+	if err != nil {
+		return nil, mongoError(err)
+	}
+
+	var entries []GlossaryEntry
+	if err = res.All(context.TODO(), &entries); err != nil {
+		return nil, mongoError(err)
+	}
+
+	return entries, nil
+}
+
+// DeleteGlossaryEntry removes the term for all language pairs and reports
+// whether anything was deleted.
+func (r *MongoRepo) DeleteGlossaryEntry(userid uint, term string) (bool, error) {
+
+	filter := bson.D{{Key: "userid", Value: userid}, {Key: "term", Value: term}}
+	res, err := r.mongo.Collection("glossary").DeleteMany(context.TODO(), filter)
+	if err != nil {
+		return false, mongoError(err)
+	}
+
+	return res.DeletedCount > 0, nil
+}
+
+// GetRandomChatTranslation returns a random translation saved in the chat.
+func (r *MongoRepo) GetRandomChatTranslation(chatid int64) (*Translation, error) {
+	return r.sampleTranslation(bson.D{{Key: "chatid", Value: chatid}})
+}
+
+// SaveChallenge replaces the active challenge of the chat.
+func (r *MongoRepo) SaveChallenge(challenge *Challenge) error {
+
+	filter := bson.D{{Key: "chatid", Value: challenge.ChatID}}
+	_, err := r.mongo.Collection("challenges").ReplaceOne(context.TODO(), filter, challenge, options.Replace().SetUpsert(true))
+	if err != nil {
+		return mongoError(err)
+	}
+
+	return nil
+}
+
+func (r *MongoRepo) GetChallenge(chatid int64) (*Challenge, error) {
+
+	res := r.mongo.Collection("challenges").FindOne(context.TODO(), bson.D{{Key: "chatid", Value: chatid}})
+	if res.Err() != nil {
+		return nil, mongoError(res.Err())
+	}
+
+	var challenge Challenge
+	err := res.Decode(&challenge)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+
+	return &challenge, nil
+}
+
+// DeleteChallenge removes the challenge posted in the message and reports
+// whether it was still active, so only the first answer wins.
+func (r *MongoRepo) DeleteChallenge(chatid int64, messageid int) (bool, error) {
+
+	filter := bson.D{{Key: "chatid", Value: chatid}, {Key: "messageid", Value: messageid}}
+	res, err := r.mongo.Collection("challenges").DeleteOne(context.TODO(), filter)
+	if err != nil {
+		return false, mongoError(err)
+	}
+
+	return res.DeletedCount > 0, nil
+}
+
+// AddScore adds points of the score to the user's points for the week.
+func (r *MongoRepo) AddScore(score *Score) error {
+	log := logger.GetLogger()
+
+	filter := bson.D{
+		{Key: "chatid", Value: score.ChatID},
+		{Key: "userid", Value: score.UserID},
+		{Key: "week", Value: score.Week},
+	}
+	update := bson.D{
+		{Key: "$inc", Value: bson.D{{Key: "points", Value: score.Points}}},
+		{Key: "$set", Value: bson.D{{Key: "username", Value: score.Username}}},
+	}
+
+	_, err := r.mongo.Collection("scores").UpdateOne(context.TODO(), filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		log.Error("Error while adding score", zap.Error(err))
+		return mongoError(err)
+	}
+
+	return nil
+}
+
+// GetLeaderboard returns users with the most points in the chat for the week,
+// or for all time if week is empty.
+func (r *MongoRepo) GetLeaderboard(chatid int64, week string, limit int) ([]Score, error) {
+
+	match := bson.D{{Key: "chatid", Value: chatid}}
+	if week != "" {
+		match = append(match, bson.E{Key: "week", Value: week})
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$userid"},
+			{Key: "userid", Value: bson.D{{Key: "$first", Value: "$userid"}}},
+			{Key: "username", Value: bson.D{{Key: "$last", Value: "$username"}}},
+			{Key: "points", Value: bson.D{{Key: "$sum", Value: "$points"}}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "points", Value: -1}, {Key: "userid", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+
+	res, err := r.mongo.Collection("scores").Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+
+	var scores []Score
+	if err = res.All(context.TODO(), &scores); err != nil {
+		return nil, mongoError(err)
+	}
+	for i := range scores {
+		scores[i].ChatID = chatid
+		scores[i].Week = week
+	}
+
+	return scores, nil
+}
+
+// userCollections hold documents of a single user, identified by userid.
+var userCollections = []string{"messages", "translations", "userconfigs", "glossary", "scores"}
+
+func (r *MongoRepo) DeleteUserData(userid uint) error {
+	log := logger.GetLogger()
+
+	for _, collection := range userCollections {
+		_, err := r.mongo.Collection(collection).DeleteMany(context.TODO(), bson.D{{Key: "userid", Value: userid}})
+		if err != nil {
+			log.Error("Error while deleting user data", zap.String("collection", collection), zap.Error(err))
+			return mongoError(err)
+		}
 	}
 
 	return nil
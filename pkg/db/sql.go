@@ -0,0 +1,452 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Drivers supported by SQLRepo.
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+)
+
+var errUnknownDriver = errors.New("Unknown sql driver, use sqlite or postgres")
+
+type dialect string
+
+func (d dialect) autoIncrement() string {
+	if d == DriverPostgres {
+		return "BIGSERIAL PRIMARY KEY"
+	}
+	return "INTEGER PRIMARY KEY AUTOINCREMENT"
+}
+
+func (d dialect) now() string {
+	if d == DriverPostgres {
+		return "CAST(EXTRACT(EPOCH FROM NOW()) AS BIGINT)"
+	}
+	return "CAST(strftime('%s', 'now') AS INTEGER)"
+}
+
+// SQLRepo stores data in SQLite or PostgreSQL.
+type SQLRepo struct {
+	db      *sql.DB
+	dialect dialect
+	// retention of messages in nanoseconds, zero keeps them forever
+	retention atomic.Int64
+}
+
+// NewSQLRepo migrates the database to the latest schema. driver is the name
+// the connection was opened with.
+func NewSQLRepo(db *sql.DB, driver string) (IRepository, error) {
+	if driver != DriverSQLite && driver != DriverPostgres {
+		return nil, errUnknownDriver
+	}
+
+	r := &SQLRepo{
+		db:      db,
+		dialect: dialect(driver),
+	}
+	if err := r.migrate(context.TODO()); err != nil {
+		return nil, fmt.Errorf("migrating database: %w", err)
+	}
+
+	return r, nil
+}
+
+// rebind replaces ? placeholders with $1, $2... for Postgres.
+func (r *SQLRepo) rebind(query string) string {
+	if r.dialect != DriverPostgres {
+		return query
+	}
+
+	var sb strings.Builder
+	n := 0
+	for _, c := range query {
+		if c == '?' {
+			n++
+			sb.WriteString(fmt.Sprintf("$%d", n))
+			continue
+		}
+		sb.WriteRune(c)
+	}
+	return sb.String()
+}
+
+func (r *SQLRepo) exec(query string, args ...any) (sql.Result, error) {
+	res, err := r.db.ExecContext(context.TODO(), r.rebind(query), args...)
+	return res, sqlError(err)
+}
+
+func (r *SQLRepo) queryRow(query string, args ...any) *sql.Row {
+	return r.db.QueryRowContext(context.TODO(), r.rebind(query), args...)
+}
+
+// sqlError maps errors of the drivers to the repository errors.
+func sqlError(err error) error {
+	var sqliteErr *sqlite.Error
+	var pqErr *pq.Error
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrNotFound
+	case errors.As(err, &sqliteErr) && (sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE || sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY):
+		return ErrConflict
+	case errors.As(err, &pqErr) && pqErr.Code == "23505":
+		return ErrConflict
+	}
+	return err
+}
+
+func (r *SQLRepo) CreateMessage(msg *Message) error {
+	if msg.CreatedAt.IsZero() {
+		msg.CreatedAt = time.Now().UTC()
+	}
+	if err := r.expireMessages(); err != nil {
+		return err
+	}
+
+	_, err := r.exec(`INSERT INTO messages (userid, chatid, text, isbot, createdat) VALUES (?, ?, ?, ?, ?)`,
+		msg.UserID, msg.ChatID, msg.Text, msg.BotMessage, msg.CreatedAt.Unix())
+
+	return err
+}
+
+func (r *SQLRepo) CountMessages(userid uint) (int64, error) {
+
+	var n int64
+	if err := r.queryRow(`SELECT COUNT(*) FROM messages WHERE userid = ?`, userid).Scan(&n); err != nil {
+		return 0, sqlError(err)
+	}
+
+	return n, nil
+}
+
+// SetMessageRetention makes CreateMessage delete expired messages.
+func (r *SQLRepo) SetMessageRetention(ttl time.Duration) error {
+	r.retention.Store(int64(ttl))
+	return r.expireMessages()
+}
+
+func (r *SQLRepo) expireMessages() error {
+	ttl := time.Duration(r.retention.Load())
+	if ttl <= 0 {
+		return nil
+	}
+
+	_, err := r.exec(`DELETE FROM messages WHERE createdat <= ?`, time.Now().Add(-ttl).Unix())
+	return err
+}
+
+func (r *SQLRepo) CreateTranslation(trnsl *Translation) error {
+
+	_, err := r.exec(`INSERT INTO translations (userid, chatid, sourcetext, targettext, source, target) VALUES (?, ?, ?, ?, ?, ?)`,
+		trnsl.UserID, trnsl.ChatID, trnsl.SourceText, trnsl.TargetText, trnsl.Source, trnsl.Target)
+
+	return err
+}
+
+// CreateTranslations inserts the rows in a single transaction.
+func (r *SQLRepo) CreateTranslations(translations []Translation) error {
+
+	tx, err := r.db.BeginTx(context.TODO(), nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(context.TODO(),
+		r.rebind(`INSERT INTO translations (userid, chatid, sourcetext, targettext, source, target) VALUES (?, ?, ?, ?, ?, ?)`))
+	if err != nil {
+		return sqlError(err)
+	}
+	defer stmt.Close()
+
+	for _, trnsl := range translations {
+		_, err := stmt.ExecContext(context.TODO(), trnsl.UserID, trnsl.ChatID, trnsl.SourceText, trnsl.TargetText, trnsl.Source, trnsl.Target)
+		if err != nil {
+			return sqlError(err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+const selectTranslation = `SELECT userid, chatid, sourcetext, targettext, source, target FROM translations`
+
+func scanTranslation(row *sql.Row) (*Translation, error) {
+	var trnsl Translation
+	err := row.Scan(&trnsl.UserID, &trnsl.ChatID, &trnsl.SourceText, &trnsl.TargetText, &trnsl.Source, &trnsl.Target)
+	if err != nil {
+		return nil, sqlError(err)
+	}
+	return &trnsl, nil
+}
+
+func (r *SQLRepo) GetRandomTranslation(userid uint) (*Translation, error) {
+	return scanTranslation(r.queryRow(selectTranslation+` WHERE userid = ? ORDER BY RANDOM() LIMIT 1`, userid))
+}
+
+func (r *SQLRepo) GetTranslations(userid uint) ([]Translation, error) {
+
+	rows, err := r.db.QueryContext(context.TODO(), r.rebind(selectTranslation+` WHERE userid = ? ORDER BY id`), userid)
+	if err != nil {
+		return nil, sqlError(err)
+	}
+	defer rows.Close()
+
+	var translations []Translation
+	for rows.Next() {
+		var trnsl Translation
+		if err := rows.Scan(&trnsl.UserID, &trnsl.ChatID, &trnsl.SourceText, &trnsl.TargetText, &trnsl.Source, &trnsl.Target); err != nil {
+			return nil, err
+		}
+		translations = append(translations, trnsl)
+	}
+
+	return translations, rows.Err()
+}
+
+func (r *SQLRepo) GetRandomChatTranslation(chatid int64) (*Translation, error) {
+	return scanTranslation(r.queryRow(selectTranslation+` WHERE chatid = ? ORDER BY RANDOM() LIMIT 1`, chatid))
+}
+
+func (r *SQLRepo) CreateConfig(cfg *Config) error {
+	if cfg.Version == 0 {
+		cfg.Version = 1
+	}
+
+	_, err := r.exec(`INSERT INTO userconfigs (userid, source, target, mode, translationword, history, version) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		cfg.UserID, cfg.Source, cfg.Target, cfg.Mode, cfg.TranslationWord, cfg.History, cfg.Version)
+
+	return err
+}
+
+func (r *SQLRepo) GetConfig(userid uint) (*Config, error) {
+
+	var cfg Config
+	err := r.queryRow(`SELECT userid, source, target, mode, translationword, history, version FROM userconfigs WHERE userid = ?`, userid).
+		Scan(&cfg.UserID, &cfg.Source, &cfg.Target, &cfg.Mode, &cfg.TranslationWord, &cfg.History, &cfg.Version)
+	if err != nil {
+		return nil, sqlError(err)
+	}
+
+	return &cfg, nil
+}
+
+func (r *SQLRepo) GetOrCreateConfig(defaults *Config) (*Config, error) {
+
+	_, err := r.exec(`INSERT INTO userconfigs (userid, source, target, mode, translationword, version) VALUES (?, ?, ?, ?, '', 1)
+		ON CONFLICT (userid) DO NOTHING`,
+		defaults.UserID, defaults.Source, defaults.Target, defaults.Mode)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetConfig(defaults.UserID)
+}
+
+// UpdateConfig sets only non-empty fields, like MongoRepo does, if the
+// version of cfg is the latest one.
+func (r *SQLRepo) UpdateConfig(cfg *Config) error {
+
+	res, err := r.exec(`UPDATE userconfigs SET
+		source = COALESCE(NULLIF(?, ''), source),
+		target = COALESCE(NULLIF(?, ''), target),
+		mode = COALESCE(NULLIF(?, ''), mode),
+		translationword = COALESCE(NULLIF(?, ''), translationword),
+		history = COALESCE(NULLIF(?, ''), history),
+		version = version + 1
+		WHERE userid = ? AND version = ?`,
+		cfg.Source, cfg.Target, cfg.Mode, cfg.TranslationWord, cfg.History, cfg.UserID, cfg.Version)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		if _, err := r.GetConfig(cfg.UserID); err != nil {
+			return err
+		}
+		return ErrConflict
+	}
+	cfg.Version++
+
+	return nil
+}
+
+func (r *SQLRepo) CreateChatConfig(cfg *ChatConfig) error {
+
+	_, err := r.exec(`INSERT INTO chatconfigs (chatid, source, target, mode) VALUES (?, ?, ?, ?)`,
+		cfg.ChatID, cfg.Source, cfg.Target, cfg.Mode)
+
+	return err
+}
+
+func (r *SQLRepo) GetChatConfig(chatid int64) (*ChatConfig, error) {
+
+	var cfg ChatConfig
+	err := r.queryRow(`SELECT chatid, source, target, mode FROM chatconfigs WHERE chatid = ?`, chatid).
+		Scan(&cfg.ChatID, &cfg.Source, &cfg.Target, &cfg.Mode)
+	if err != nil {
+		return nil, sqlError(err)
+	}
+
+	return &cfg, nil
+}
+
+func (r *SQLRepo) UpdateChatConfig(cfg *ChatConfig) error {
+
+	_, err := r.exec(`UPDATE chatconfigs SET
+		source = COALESCE(NULLIF(?, ''), source),
+		target = COALESCE(NULLIF(?, ''), target),
+		mode = COALESCE(NULLIF(?, ''), mode)
+		WHERE chatid = ?`,
+		cfg.Source, cfg.Target, cfg.Mode, cfg.ChatID)
+
+	return err
+}
+
+func (r *SQLRepo) SaveGlossaryEntry(entry *GlossaryEntry) error {
+
+	_, err := r.exec(`INSERT INTO glossary (userid, source, target, term, translation) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (userid, source, target, term) DO UPDATE SET translation = excluded.translation`,
+		entry.UserID, entry.Source, entry.Target, entry.Term, entry.Translation)
+
+	return err
+}
+
+func (r *SQLRepo) GetGlossary(userid uint) ([]GlossaryEntry, error) {
+
+	rows, err := r.db.QueryContext(context.TODO(),
+		r.rebind(`SELECT userid, source, target, term, translation FROM glossary WHERE userid = ?`), userid)
+	if err != nil {
+		return nil, sqlError(err)
+	}
+	defer rows.Close()
+
+	var entries []GlossaryEntry
+	for rows.Next() {
+		var e GlossaryEntry
+		if err := rows.Scan(&e.UserID, &e.Source, &e.Target, &e.Term, &e.Translation); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+func (r *SQLRepo) DeleteGlossaryEntry(userid uint, term string) (bool, error) {
+
+	res, err := r.exec(`DELETE FROM glossary WHERE userid = ? AND term = ?`, userid, term)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *SQLRepo) SaveChallenge(challenge *Challenge) error {
+
+	_, err := r.exec(`INSERT INTO challenges (chatid, messageid, word, answer) VALUES (?, ?, ?, ?)
+		ON CONFLICT (chatid) DO UPDATE SET messageid = excluded.messageid, word = excluded.word, answer = excluded.answer`,
+		challenge.ChatID, challenge.MessageID, challenge.Word, challenge.Answer)
+
+	return err
+}
+
+func (r *SQLRepo) GetChallenge(chatid int64) (*Challenge, error) {
+
+	var challenge Challenge
+	err := r.queryRow(`SELECT chatid, messageid, word, answer FROM challenges WHERE chatid = ?`, chatid).
+		Scan(&challenge.ChatID, &challenge.MessageID, &challenge.Word, &challenge.Answer)
+	if err != nil {
+		return nil, sqlError(err)
+	}
+
+	return &challenge, nil
+}
+
+func (r *SQLRepo) DeleteChallenge(chatid int64, messageid int) (bool, error) {
+
+	res, err := r.exec(`DELETE FROM challenges WHERE chatid = ? AND messageid = ?`, chatid, messageid)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *SQLRepo) AddScore(score *Score) error {
+
+	_, err := r.exec(`INSERT INTO scores (chatid, userid, week, username, points) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (chatid, userid, week) DO UPDATE SET points = scores.points + excluded.points, username = excluded.username`,
+		score.ChatID, score.UserID, score.Week, score.Username, score.Points)
+
+	return err
+}
+
+func (r *SQLRepo) GetLeaderboard(chatid int64, week string, limit int) ([]Score, error) {
+
+	query := `SELECT chatid, userid, week, username, points FROM scores WHERE chatid = ?`
+	args := []any{chatid}
+	if week != "" {
+		query += ` AND week = ?`
+		args = append(args, week)
+	}
+	query += ` ORDER BY id`
+
+	rows, err := r.db.QueryContext(context.TODO(), r.rebind(query), args...)
+	if err != nil {
+		return nil, sqlError(err)
+	}
+	defer rows.Close()
+
+	var scores []Score
+	for rows.Next() {
+		var s Score
+		if err := rows.Scan(&s.ChatID, &s.UserID, &s.Week, &s.Username, &s.Points); err != nil {
+			return nil, err
+		}
+		scores = append(scores, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return rankScores(scores, week, limit), nil
+}
+
+// DeleteUserData deletes rows of the user from every table in a single
+// transaction.
+func (r *SQLRepo) DeleteUserData(userid uint) error {
+
+	tx, err := r.db.BeginTx(context.TODO(), nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"messages", "translations", "userconfigs", "glossary", "scores"} {
+		if _, err := tx.ExecContext(context.TODO(), r.rebind(`DELETE FROM `+table+` WHERE userid = ?`), userid); err != nil {
+			return sqlError(err)
+		}
+	}
+
+	return tx.Commit()
+}
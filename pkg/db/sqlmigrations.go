@@ -0,0 +1,148 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+// sqlMigration changes the schema from version-1 to version. {{id}} in the
+// statements is replaced with the auto increment primary key of the dialect
+// and {{now}} with the current unix time.
+type sqlMigration struct {
+	version    int
+	statements []string
+}
+
+// sqlMigrations are applied in order and must never be changed once
+// released, add a new migration instead.
+var sqlMigrations = []sqlMigration{
+	{
+		version: 1,
+		statements: []string{
+			`CREATE TABLE messages (
+				id {{id}},
+				userid BIGINT NOT NULL,
+				chatid BIGINT NOT NULL,
+				text TEXT NOT NULL,
+				isbot BOOLEAN NOT NULL
+			)`,
+			`CREATE TABLE translations (
+				id {{id}},
+				userid BIGINT NOT NULL,
+				chatid BIGINT NOT NULL,
+				sourcetext TEXT NOT NULL,
+				targettext TEXT NOT NULL,
+				source TEXT NOT NULL,
+				target TEXT NOT NULL
+			)`,
+			`CREATE INDEX translations_chatid ON translations (chatid)`,
+			`CREATE TABLE userconfigs (
+				userid BIGINT PRIMARY KEY,
+				source TEXT NOT NULL,
+				target TEXT NOT NULL,
+				mode TEXT NOT NULL,
+				translationword TEXT NOT NULL
+			)`,
+			`CREATE TABLE chatconfigs (
+				chatid BIGINT PRIMARY KEY,
+				source TEXT NOT NULL,
+				target TEXT NOT NULL,
+				mode TEXT NOT NULL
+			)`,
+			`CREATE TABLE glossary (
+				userid BIGINT NOT NULL,
+				source TEXT NOT NULL,
+				target TEXT NOT NULL,
+				term TEXT NOT NULL,
+				translation TEXT NOT NULL,
+				PRIMARY KEY (userid, source, target, term)
+			)`,
+			`CREATE TABLE challenges (
+				chatid BIGINT PRIMARY KEY,
+				messageid BIGINT NOT NULL,
+				word TEXT NOT NULL,
+				answer TEXT NOT NULL
+			)`,
+			`CREATE TABLE scores (
+				id {{id}},
+				chatid BIGINT NOT NULL,
+				userid BIGINT NOT NULL,
+				week TEXT NOT NULL,
+				username TEXT NOT NULL,
+				points INTEGER NOT NULL,
+				UNIQUE (chatid, userid, week)
+			)`,
+		},
+	},
+	{
+		version: 2,
+		statements: []string{
+			`CREATE INDEX translations_userid ON translations (userid, source, target)`,
+		},
+	},
+	{
+		version: 3,
+		statements: []string{
+			`ALTER TABLE userconfigs ADD COLUMN version INTEGER NOT NULL DEFAULT 1`,
+		},
+	},
+	{
+		version: 4,
+		statements: []string{
+			// Unix seconds, old messages are kept as if created now
+			`ALTER TABLE messages ADD COLUMN createdat BIGINT NOT NULL DEFAULT 0`,
+			`UPDATE messages SET createdat = {{now}}`,
+			`CREATE INDEX messages_createdat ON messages (createdat)`,
+			`CREATE INDEX messages_userid ON messages (userid)`,
+			`ALTER TABLE userconfigs ADD COLUMN history TEXT NOT NULL DEFAULT ''`,
+		},
+	},
+}
+
+// migrate applies migrations newer than the version stored in the
+// schema_migrations table, each one in its own transaction.
+func (r *SQLRepo) migrate(ctx context.Context) error {
+
+	_, err := r.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)`)
+	if err != nil {
+		return err
+	}
+
+	var current sql.NullInt64
+	if err := r.db.QueryRowContext(ctx, `SELECT MAX(version) FROM schema_migrations`).Scan(&current); err != nil {
+		return err
+	}
+
+	for _, m := range sqlMigrations {
+		if int64(m.version) <= current.Int64 {
+			continue
+		}
+		if err := r.apply(ctx, m); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *SQLRepo) apply(ctx context.Context, m sqlMigration) error {
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, statement := range m.statements {
+		statement = strings.NewReplacer("{{id}}", r.dialect.autoIncrement(), "{{now}}", r.dialect.now()).Replace(statement)
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, r.rebind(`INSERT INTO schema_migrations (version) VALUES (?)`), m.version); err != nil {
+		return sqlError(err)
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,36 @@
+package dictionary
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrNotFound = errors.New("Word is not found in the dictionary")
+
+// Meaning is a single sense of a word with usage examples.
+type Meaning struct {
+	Text     string
+	Examples []string
+}
+
+// Entry describes a word used as one part of speech.
+type Entry struct {
+	Word          string
+	PartOfSpeech  string
+	Transcription string
+	Meanings      []Meaning
+	// Translations are the word's translations into the requested language.
+	Translations []string
+}
+
+// Provider looks up dictionary entries for a word in the source language.
+// Translations are given for the target language when the provider has them.
+type Provider interface {
+	Lookup(word string, source string, target string) ([]Entry, error)
+}
+
+// IsWord reports whether text is a single word that is worth looking up.
+func IsWord(text string) bool {
+	text = strings.TrimSpace(text)
+	return text != "" && !strings.ContainsAny(text, " \t\n") && len([]rune(text)) <= 50
+}
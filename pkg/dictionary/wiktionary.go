@@ -0,0 +1,174 @@
+package dictionary
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+)
+
+// wiktionaryEntry is a line of the JSON Lines Wiktionary dump published
+// by kaikki.org (wiktextract).
+type wiktionaryEntry struct {
+	Word     string `json:"word"`
+	LangCode string `json:"lang_code"`
+	Pos      string `json:"pos"`
+	Sounds   []struct {
+		IPA string `json:"ipa"`
+	} `json:"sounds"`
+	Senses []struct {
+		Glosses  []string `json:"glosses"`
+		Examples []struct {
+			Text string `json:"text"`
+		} `json:"examples"`
+		Translations []wiktionaryTranslation `json:"translations"`
+	} `json:"senses"`
+	Translations []wiktionaryTranslation `json:"translations"`
+}
+
+type wiktionaryTranslation struct {
+	Code string `json:"code"`
+	Word string `json:"word"`
+}
+
+type storedEntry struct {
+	Entry
+	translations map[string][]string
+}
+
+// FileProvider is an offline dictionary loaded into memory from a dump file.
+type FileProvider struct {
+	// words maps language code and lowercased word to entries
+	words map[string]map[string][]storedEntry
+}
+
+// LoadWiktionary reads a wiktextract JSON Lines dump from path. When languages
+// are given, words of other languages are skipped to save memory.
+func LoadWiktionary(path string, languages ...string) (*FileProvider, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadWiktionary(f, languages...)
+}
+
+// ReadWiktionary reads a wiktextract JSON Lines dump from r.
+func ReadWiktionary(r io.Reader, languages ...string) (*FileProvider, error) {
+	keep := make(map[string]bool, len(languages))
+	for _, lang := range languages {
+		keep[lang] = true
+	}
+
+	p := &FileProvider{words: make(map[string]map[string][]storedEntry)}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		if len(keep) > 0 {
+			// Decoding the language alone is much cheaper than the whole entry
+			var lang struct {
+				LangCode string `json:"lang_code"`
+			}
+			if err := json.Unmarshal(line, &lang); err != nil {
+				return nil, err
+			}
+			if !keep[lang.LangCode] {
+				continue
+			}
+		}
+
+		var we wiktionaryEntry
+		if err := json.Unmarshal(line, &we); err != nil {
+			return nil, err
+		}
+		if we.Word == "" {
+			continue
+		}
+		p.add(we)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (p *FileProvider) add(we wiktionaryEntry) {
+	entry := storedEntry{
+		Entry: Entry{
+			Word:         we.Word,
+			PartOfSpeech: we.Pos,
+		},
+		translations: make(map[string][]string),
+	}
+	for _, s := range we.Sounds {
+		if s.IPA != "" {
+			entry.Transcription = s.IPA
+			break
+		}
+	}
+
+	addTranslations := func(translations []wiktionaryTranslation) {
+		for _, t := range translations {
+			if t.Code != "" && t.Word != "" && !contains(entry.translations[t.Code], t.Word) {
+				entry.translations[t.Code] = append(entry.translations[t.Code], t.Word)
+			}
+		}
+	}
+	addTranslations(we.Translations)
+
+	for _, s := range we.Senses {
+		addTranslations(s.Translations)
+		if len(s.Glosses) == 0 {
+			continue
+		}
+		meaning := Meaning{Text: strings.Join(s.Glosses, "; ")}
+		for _, ex := range s.Examples {
+			if ex.Text != "" {
+				meaning.Examples = append(meaning.Examples, ex.Text)
+			}
+		}
+		entry.Meanings = append(entry.Meanings, meaning)
+	}
+
+	lang := p.words[we.LangCode]
+	if lang == nil {
+		lang = make(map[string][]storedEntry)
+		p.words[we.LangCode] = lang
+	}
+	key := strings.ToLower(we.Word)
+	lang[key] = append(lang[key], entry)
+}
+
+func (p *FileProvider) Lookup(word string, source string, target string) ([]Entry, error) {
+
+	stored := p.words[source][strings.ToLower(strings.TrimSpace(word))]
+	if len(stored) == 0 {
+		return nil, ErrNotFound
+	}
+
+	entries := make([]Entry, len(stored))
+	for i, s := range stored {
+		entries[i] = s.Entry
+		entries[i].Translations = s.translations[target]
+	}
+
+	return entries, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package dictionary
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const dump = `{"word":"car","lang_code":"en","pos":"noun","sounds":[{"ipa":"/kɑː/"}],"senses":[{"glosses":["A wheeled vehicle."],"examples":[{"text":"She drove her car to work."}]},{"glosses":["A railway carriage."]}],"translations":[{"code":"ru","word":"автомобиль"},{"code":"ru","word":"машина"},{"code":"de","word":"Auto"}]}
+{"word":"run","lang_code":"en","pos":"verb","senses":[{"glosses":["To move swiftly."],"translations":[{"code":"ru","word":"бежать"}]}]}
+{"word":"run","lang_code":"en","pos":"noun","senses":[{"glosses":["An act of running."]}]}
+{"word":"машина","lang_code":"ru","pos":"noun","senses":[{"glosses":["автомобиль"]}]}
+`
+
+func TestFileProvider_Lookup(t *testing.T) {
+
+	p, err := ReadWiktionary(strings.NewReader(dump), "en")
+	assert.NoError(t, err)
+
+	entries, err := p.Lookup("Car", "en", "ru")
+	assert.NoError(t, err)
+	assert.Equal(t, []Entry{
+		{
+			Word:          "car",
+			PartOfSpeech:  "noun",
+			Transcription: "/kɑː/",
+			Meanings: []Meaning{
+				{Text: "A wheeled vehicle.", Examples: []string{"She drove her car to work."}},
+				{Text: "A railway carriage."},
+			},
+			Translations: []string{"автомобиль", "машина"},
+		},
+	}, entries)
+
+	entries, err = p.Lookup("run", "en", "ru")
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, "verb", entries[0].PartOfSpeech)
+		assert.Equal(t, []string{"бежать"}, entries[0].Translations)
+		assert.Equal(t, "noun", entries[1].PartOfSpeech)
+	}
+
+	// Russian words were skipped while loading
+	_, err = p.Lookup("машина", "ru", "en")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestReadWiktionary_Languages(t *testing.T) {
+
+	p, err := ReadWiktionary(strings.NewReader(dump), "en", "ru")
+	assert.NoError(t, err)
+
+	entries, err := p.Lookup("машина", "ru", "en")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	// Skipped words are not decoded past their language
+	_, err = ReadWiktionary(strings.NewReader(`{"word":"Auto","lang_code":"de","senses":"unexpected"}`), "en")
+	assert.NoError(t, err)
+
+	// All languages are kept without a filter
+	p, err = ReadWiktionary(strings.NewReader(dump))
+	assert.NoError(t, err)
+	entries, err = p.Lookup("car", "en", "de")
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, []string{"Auto"}, entries[0].Translations)
+	}
+}
@@ -0,0 +1,159 @@
+package glossary
+
+import (
+	"html"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Entry is a term and the translation it must always get.
+type Entry struct {
+	Term        string
+	Translation string
+}
+
+// Glossary replaces known terms with placeholders before a text is sent to
+// a translator and puts the fixed translations in their place afterwards.
+type Glossary struct {
+	terms        [][]rune
+	translations []string
+}
+
+// placeholderRe also matches placeholders the translator added spaces into.
+var placeholderRe = regexp.MustCompile(`\{\s*\{\s*(\d+)\s*\}\s*\}`)
+
+// markupRe matches tags and character references of html.
+var markupRe = regexp.MustCompile(`<[^>]*>|&(?:#[0-9]+|#[xX][0-9a-fA-F]+|[a-zA-Z][a-zA-Z0-9]*);`)
+
+func New(entries []Entry) *Glossary {
+	sorted := make([]Entry, 0, len(entries))
+	for _, e := range entries {
+		if strings.TrimSpace(e.Term) != "" {
+			sorted = append(sorted, e)
+		}
+	}
+	// Longer terms win over terms they contain.
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return len([]rune(sorted[i].Term)) > len([]rune(sorted[j].Term))
+	})
+
+	g := &Glossary{}
+	for _, e := range sorted {
+		g.terms = append(g.terms, []rune(strings.ToLower(strings.TrimSpace(e.Term))))
+		g.translations = append(g.translations, e.Translation)
+	}
+
+	return g
+}
+
+// Substitutions holds translations of the terms found by Protect.
+type Substitutions []string
+
+// Protect replaces whole-word, case-insensitive occurrences of glossary terms
+// in text with placeholders.
+func (g *Glossary) Protect(text string) (string, Substitutions) {
+	if len(g.terms) == 0 {
+		return text, nil
+	}
+
+	var b strings.Builder
+	var subs Substitutions
+	g.protect(&b, &subs, []rune(text))
+
+	return b.String(), subs
+}
+
+// ProtectHTML is Protect for an html fragment: terms are only looked for in
+// the text, tags and character references are left as they are.
+func (g *Glossary) ProtectHTML(text string) (string, Substitutions) {
+	if len(g.terms) == 0 {
+		return text, nil
+	}
+
+	var b strings.Builder
+	var subs Substitutions
+	for text != "" {
+		loc := markupRe.FindStringIndex(text)
+		if loc == nil {
+			g.protect(&b, &subs, []rune(text))
+			break
+		}
+		g.protect(&b, &subs, []rune(text[:loc[0]]))
+		b.WriteString(text[loc[0]:loc[1]])
+		text = text[loc[1]:]
+	}
+
+	return b.String(), subs
+}
+
+// protect writes runes to b replacing the terms found.
+func (g *Glossary) protect(b *strings.Builder, subs *Substitutions, runes []rune) {
+	for i := 0; i < len(runes); {
+		if i == 0 || !isWordRune(runes[i-1]) {
+			if n, idx := g.match(runes[i:]); n > 0 {
+				b.WriteString("{{" + strconv.Itoa(len(*subs)) + "}}")
+				*subs = append(*subs, g.translations[idx])
+				i += n
+				continue
+			}
+		}
+		b.WriteRune(runes[i])
+		i++
+	}
+}
+
+// match returns the length of the glossary term text starts with and its index.
+func (g *Glossary) match(text []rune) (int, int) {
+	for idx, term := range g.terms {
+		if len(term) > len(text) {
+			continue
+		}
+		if len(term) < len(text) && isWordRune(text[len(term)]) {
+			continue
+		}
+
+		ok := true
+		for i, r := range term {
+			if unicode.ToLower(text[i]) != r {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return len(term), idx
+		}
+	}
+
+	return 0, -1
+}
+
+// Restore puts the translations of protected terms back into translated text.
+func (s Substitutions) Restore(translated string) string {
+	return s.restore(translated, func(t string) string { return t })
+}
+
+// RestoreHTML is Restore for an html fragment, the translations are escaped.
+func (s Substitutions) RestoreHTML(translated string) string {
+	return s.restore(translated, html.EscapeString)
+}
+
+func (s Substitutions) restore(translated string, escape func(string) string) string {
+	if len(s) == 0 {
+		return translated
+	}
+
+	return placeholderRe.ReplaceAllStringFunc(translated, func(placeholder string) string {
+		idx, err := strconv.Atoi(placeholderRe.FindStringSubmatch(placeholder)[1])
+		if err != nil || idx >= len(s) {
+			return placeholder
+		}
+		return escape(s[idx])
+	})
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
@@ -0,0 +1,77 @@
+package glossary
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGlossary_Protect(t *testing.T) {
+
+	g := New([]Entry{
+		{Term: "pull request", Translation: "пулл-реквест"},
+		{Term: "pull", Translation: "пулл"},
+		{Term: "деплой", Translation: "deploy"},
+	})
+
+	tests := []struct {
+		name          string
+		text          string
+		wantProtected string
+		wantSubs      Substitutions
+	}{
+		{
+			name:          "Longest term wins",
+			text:          "Review my Pull Request please",
+			wantProtected: "Review my {{0}} please",
+			wantSubs:      Substitutions{"пулл-реквест"},
+		},
+		{
+			name:          "Whole words only",
+			text:          "pulling the pull",
+			wantProtected: "pulling the {{0}}",
+			wantSubs:      Substitutions{"пулл"},
+		},
+		{
+			name:          "Unicode terms",
+			text:          "Деплой, деплой!",
+			wantProtected: "{{0}}, {{1}}!",
+			wantSubs:      Substitutions{"deploy", "deploy"},
+		},
+		{
+			name:          "No terms",
+			text:          "nothing here",
+			wantProtected: "nothing here",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			protected, subs := g.Protect(tt.text)
+			assert.Equal(t, tt.wantProtected, protected)
+			assert.Equal(t, tt.wantSubs, subs)
+		})
+	}
+}
+
+func TestSubstitutions_Restore(t *testing.T) {
+
+	subs := Substitutions{"пулл-реквест", "деплой"}
+
+	assert.Equal(t, "Проверь мой пулл-реквест и деплой", subs.Restore("Проверь мой {{0}} и {{1}}"))
+	assert.Equal(t, "Проверь мой пулл-реквест", subs.Restore("Проверь мой { {0} }"))
+	assert.Equal(t, "{{5}}", subs.Restore("{{5}}"))
+	assert.Equal(t, "text", Substitutions(nil).Restore("text"))
+}
+
+func TestGlossary_ProtectHTML(t *testing.T) {
+
+	g := New([]Entry{{Term: "link", Translation: "ссылка"}, {Term: "amp", Translation: "усилитель"}, {Term: "b", Translation: "<b>"}})
+
+	protected, subs := g.ProtectHTML(`<a href="https://link.com/link" title="link">link</a> &amp; amp <b>b</b>`)
+	assert.Equal(t, `<a href="https://link.com/link" title="link">{{0}}</a> &amp; {{1}} <b>{{2}}</b>`, protected)
+	assert.Equal(t, Substitutions{"ссылка", "усилитель", "<b>"}, subs)
+
+	assert.Equal(t, `<a href="x">ссылка</a> &amp; усилитель <b>&lt;b&gt;</b>`,
+		subs.RestoreHTML(`<a href="x">{{0}}</a> &amp; {{1}} <b>{{2}}</b>`))
+}
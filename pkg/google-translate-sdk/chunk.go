@@ -0,0 +1,86 @@
+package gTranslate
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// splitText is a text cut into chunks along with the whitespace between them,
+// so translated chunks can be joined back keeping the original layout.
+type splitText struct {
+	chunks     []string
+	separators []string
+}
+
+func (s splitText) join(translated []string) string {
+	var b strings.Builder
+	for i, chunk := range translated {
+		if i > 0 {
+			b.WriteString(s.separators[i-1])
+		}
+		b.WriteString(chunk)
+	}
+	return b.String()
+}
+
+// splitSentences cuts text into chunks of at most limit runes. Chunks end on
+// sentence boundaries where possible, then on whitespace, and only then a
+// sentence is cut in the middle of a word.
+func splitSentences(text string, limit int) splitText {
+	if utf8.RuneCountInString(text) <= limit {
+		return splitText{chunks: []string{text}}
+	}
+
+	var res splitText
+	rest := text
+	for utf8.RuneCountInString(rest) > limit {
+		cut := cutIndex(rest, limit)
+		chunk := strings.TrimRightFunc(rest[:cut], unicode.IsSpace)
+		next := strings.TrimLeftFunc(rest[cut:], unicode.IsSpace)
+
+		res.chunks = append(res.chunks, chunk)
+		res.separators = append(res.separators, rest[len(chunk):len(rest)-len(next)])
+		rest = next
+	}
+	res.chunks = append(res.chunks, rest)
+
+	return res
+}
+
+// cutIndex returns the byte index to cut text at, so that text[:index]
+// holds at most limit runes.
+func cutIndex(text string, limit int) int {
+	sentence, space, runes := -1, -1, 0
+	var prev rune
+
+	for i, r := range text {
+		if runes == limit {
+			switch {
+			case sentence > 0:
+				return sentence
+			case space > 0:
+				return space
+			}
+			return i
+		}
+		if unicode.IsSpace(r) {
+			if r == '\n' || isSentenceEnd(prev) {
+				sentence = i
+			}
+			space = i
+		}
+		prev = r
+		runes++
+	}
+
+	return len(text)
+}
+
+func isSentenceEnd(r rune) bool {
+	switch r {
+	case '.', '!', '?', '…', '。', '！', '？':
+		return true
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package gTranslate
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitSentences(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		text  string
+		limit int
+		want  []string
+	}{
+		{
+			name:  "Short text",
+			text:  "One sentence.",
+			limit: 20,
+			want:  []string{"One sentence."},
+		},
+		{
+			name:  "Sentence boundaries",
+			text:  "First one. Second one! Third one?",
+			limit: 24,
+			want:  []string{"First one. Second one!", "Third one?"},
+		},
+		{
+			name:  "New lines",
+			text:  "First line\nSecond line",
+			limit: 15,
+			want:  []string{"First line", "Second line"},
+		},
+		{
+			name:  "Long sentence cut on spaces",
+			text:  "a very long sentence without end",
+			limit: 10,
+			want:  []string{"a very", "long", "sentence", "without", "end"},
+		},
+		{
+			name:  "Long word",
+			text:  "абвгдеёжзи",
+			limit: 4,
+			want:  []string{"абвг", "деёж", "зи"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitSentences(tt.text, tt.limit)
+			assert.Equal(t, tt.want, got.chunks)
+			for _, chunk := range got.chunks {
+				assert.LessOrEqual(t, utf8.RuneCountInString(chunk), tt.limit)
+			}
+			assert.Equal(t, tt.text, got.join(got.chunks))
+		})
+	}
+}
+
+func TestClient_TranslateLongText(t *testing.T) {
+
+	requests := 0
+	c := newBatchClientMock(t, &requests)
+
+	// 30 sentences of 500 characters fit into three chunks of ten sentences.
+	sentence := strings.Repeat("word ", 99) + "end. "
+	text := strings.Repeat(sentence, 30)
+
+	got, err := c.TranslateText(text, "ru", "en")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, requests)
+	assert.Equal(t, 3, strings.Count(got, "t:"))
+}
+
+func TestRedactURL(t *testing.T) {
+	assert.Equal(t,
+		"https://translation.googleapis.com/language/translate/v2?key=REDACTED&q=car",
+		RedactURL("https://translation.googleapis.com/language/translate/v2?key=secret&q=car"))
+	assert.Equal(t,
+		"https://translation.googleapis.com/language/translate/v2",
+		RedactURL("https://translation.googleapis.com/language/translate/v2"))
+}
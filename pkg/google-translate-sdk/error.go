@@ -0,0 +1,160 @@
+package gTranslate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ErrorKind classifies errors returned by Google's api.
+type ErrorKind int
+
+const (
+	KindUnknown ErrorKind = iota
+	KindInvalidKey
+	KindQuotaExceeded
+	KindBadLanguage
+	KindTextTooLong
+	KindServer
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case KindInvalidKey:
+		return "invalid key"
+	case KindQuotaExceeded:
+		return "quota exceeded"
+	case KindBadLanguage:
+		return "bad language"
+	case KindTextTooLong:
+		return "text too long"
+	case KindServer:
+		return "server error"
+	}
+	return "unknown"
+}
+
+// APIError is returned when Google's api responds with a non-200 status.
+// Use errors.As to inspect it.
+type APIError struct {
+	StatusCode int
+	Kind       ErrorKind
+	Status     string
+	Reason     string
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	return fmt.Sprintf("google translate api: %v (%d): %v", e.Kind, e.StatusCode, msg)
+}
+
+// Unwrap allows matching any api error with errors.Is(err, errForeighApi).
+func (e *APIError) Unwrap() error {
+	return errForeighApi
+}
+
+// Temporary reports whether the request may succeed if repeated later.
+func (e *APIError) Temporary() bool {
+	return e.Kind == KindServer || e.Kind == KindQuotaExceeded
+}
+
+type errorDetail struct {
+	Reason string `json:"reason"`
+}
+
+type errorBody struct {
+	Error struct {
+		Code    int           `json:"code"`
+		Message string        `json:"message"`
+		Status  string        `json:"status"`
+		Errors  []errorDetail `json:"errors"`
+		Details []errorDetail `json:"details"`
+	} `json:"error"`
+}
+
+// parseAPIError builds an APIError from the error json of a failed response.
+func parseAPIError(resp *http.Response) *APIError {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+
+	var body errorBody
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if err == nil && json.Unmarshal(data, &body) == nil {
+		apiErr.Message = body.Error.Message
+		apiErr.Status = body.Error.Status
+		for _, d := range append(body.Error.Details, body.Error.Errors...) {
+			if d.Reason != "" {
+				apiErr.Reason = d.Reason
+				break
+			}
+		}
+	}
+	apiErr.Kind = classify(apiErr)
+
+	return apiErr
+}
+
+func classify(e *APIError) ErrorKind {
+	reason := strings.ToLower(e.Reason)
+	message := strings.ToLower(e.Message)
+
+	switch {
+	case reason == "api_key_invalid" || reason == "keyinvalid" || strings.Contains(message, "api key not valid"):
+		return KindInvalidKey
+	case e.StatusCode == http.StatusTooManyRequests || e.Status == "RESOURCE_EXHAUSTED" ||
+		strings.Contains(reason, "limitexceeded") || strings.Contains(reason, "rate_limit_exceeded"):
+		return KindQuotaExceeded
+	case e.StatusCode == http.StatusRequestEntityTooLarge || strings.Contains(message, "too long") ||
+		strings.Contains(message, "payload size exceeds"):
+		return KindTextTooLong
+	case strings.Contains(message, "language") || (e.StatusCode == http.StatusBadRequest && reason == "invalid"):
+		return KindBadLanguage
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden:
+		return KindInvalidKey
+	case e.StatusCode >= http.StatusInternalServerError:
+		return KindServer
+	}
+	return KindUnknown
+}
+
+// secretParams are query parameters that must never get into logs.
+var secretParams = []string{"key", "access_token"}
+
+// RedactURL hides secret query parameters of rawURL so it can be logged.
+func RedactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	q := u.Query()
+	redacted := false
+	for _, param := range secretParams {
+		if q.Has(param) {
+			q.Set(param, "REDACTED")
+			redacted = true
+		}
+	}
+	if !redacted {
+		return rawURL
+	}
+	u.RawQuery = q.Encode()
+
+	return u.String()
+}
+
+// redactError hides secrets in the url of errors returned by http.Client.
+func redactError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = RedactURL(urlErr.URL)
+	}
+	return err
+}
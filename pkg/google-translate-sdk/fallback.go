@@ -0,0 +1,60 @@
+package gTranslate
+
+import (
+	"context"
+	"errors"
+)
+
+// FallbackClient uses the fallback translator when the primary one is
+// unavailable. Errors caused by the request itself are returned as is.
+type FallbackClient struct {
+	primary  IClient
+	fallback IClient
+}
+
+func NewFallbackClient(primary IClient, fallback IClient) IClient {
+	return &FallbackClient{
+		primary:  primary,
+		fallback: fallback,
+	}
+}
+
+func (c *FallbackClient) TranslateText(text string, target string, source string) (string, error) {
+	translated, err := c.primary.TranslateText(text, target, source)
+	if err != nil && shouldFallback(err) {
+		if fallbackTranslated, fallbackErr := c.fallback.TranslateText(text, target, source); fallbackErr == nil {
+			return fallbackTranslated, nil
+		}
+	}
+	return translated, err
+}
+
+func (c *FallbackClient) TranslateBatch(ctx context.Context, texts []string, target string, source string) ([]string, error) {
+	translated, err := c.primary.TranslateBatch(ctx, texts, target, source)
+	if err != nil && ctx.Err() == nil && shouldFallback(err) {
+		if fallbackTranslated, fallbackErr := c.fallback.TranslateBatch(ctx, texts, target, source); fallbackErr == nil {
+			return fallbackTranslated, nil
+		}
+	}
+	return translated, err
+}
+
+// shouldFallback reports whether err means the service is down or
+// unreachable rather than the request being wrong.
+func shouldFallback(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Temporary()
+	}
+	return !errors.Is(err, context.Canceled)
+}
+
+func (c *FallbackClient) TranslateHTML(ctx context.Context, html string, target string, source string) (string, error) {
+	translated, err := c.primary.TranslateHTML(ctx, html, target, source)
+	if err != nil && ctx.Err() == nil && shouldFallback(err) {
+		if fallbackTranslated, fallbackErr := c.fallback.TranslateHTML(ctx, html, target, source); fallbackErr == nil {
+			return fallbackTranslated, nil
+		}
+	}
+	return translated, err
+}
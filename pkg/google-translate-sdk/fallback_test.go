@@ -0,0 +1,81 @@
+package gTranslate
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type staticClient struct {
+	text string
+	err  error
+}
+
+func (c staticClient) TranslateText(text string, target string, source string) (string, error) {
+	return c.text, c.err
+}
+
+func (c staticClient) TranslateBatch(ctx context.Context, texts []string, target string, source string) ([]string, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return []string{c.text}, nil
+}
+
+func (c staticClient) TranslateHTML(ctx context.Context, html string, target string, source string) (string, error) {
+	return c.text, c.err
+}
+
+func TestFallbackClient(t *testing.T) {
+
+	fallback := staticClient{text: "offline"}
+
+	tests := []struct {
+		name    string
+		primary staticClient
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "Primary ok",
+			primary: staticClient{text: "online"},
+			want:    "online",
+		},
+		{
+			name:    "Server error",
+			primary: staticClient{err: &APIError{StatusCode: http.StatusServiceUnavailable, Kind: KindServer}},
+			want:    "offline",
+		},
+		{
+			name:    "Network error",
+			primary: staticClient{err: errors.New("dial tcp: connection refused")},
+			want:    "offline",
+		},
+		{
+			name:    "Bad request",
+			primary: staticClient{err: &APIError{StatusCode: http.StatusBadRequest, Kind: KindBadLanguage}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewFallbackClient(tt.primary, fallback)
+
+			got, err := c.TranslateText("car", "ru", "en")
+			batch, batchErr := c.TranslateBatch(context.Background(), []string{"car"}, "ru", "en")
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Error(t, batchErr)
+			} else {
+				assert.NoError(t, err)
+				assert.NoError(t, batchErr)
+				assert.Equal(t, tt.want, got)
+				assert.Equal(t, []string{tt.want}, batch)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package gTranslate
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type RetryConfig struct {
+	// MaxAttempts is the total number of tries for a single request.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry, doubled on each next one.
+	BaseDelay time.Duration
+	// MaxDelay limits the delay between attempts. A longer Retry-After
+	// returned by the server stops retrying.
+	MaxDelay time.Duration
+}
+
+var defaultRetry = RetryConfig{
+	MaxAttempts: 3,
+	BaseDelay:   200 * time.Millisecond,
+	MaxDelay:    5 * time.Second,
+}
+
+func (c *Client) do(ctx context.Context, newRequest func(ctx context.Context) (*http.Request, error)) (*http.Response, error) {
+	return doWithRetry(ctx, c.client, c.config.Retry, c.limiter, newRequest)
+}
+
+// doWithRetry sends the request built by newRequest, repeating it on network
+// errors, 429 and 5xx responses. Any non-200 response is returned as *APIError.
+func doWithRetry(ctx context.Context, client *http.Client, retry RetryConfig, limiter *rateLimiter,
+	newRequest func(ctx context.Context) (*http.Request, error)) (*http.Response, error) {
+	attempts := retry.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		if limiter != nil {
+			if err := limiter.Wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		req, err := newRequest(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var retryAfter time.Duration
+		resp, err := client.Do(req)
+		if err != nil {
+			err = redactError(err)
+			if ctx.Err() != nil || attempt == attempts {
+				return nil, err
+			}
+		} else if resp.StatusCode == http.StatusOK {
+			return resp, nil
+		} else {
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+			apiErr := parseAPIError(resp)
+			resp.Body.Close()
+			if !retryableStatus(resp.StatusCode) || attempt == attempts {
+				return nil, apiErr
+			}
+			err = apiErr
+		}
+
+		delay := retry.backoff(attempt)
+		if retryAfter > 0 {
+			if retryAfter > retry.MaxDelay {
+				return nil, err
+			}
+			delay = retryAfter
+		}
+		if sleepErr := sleep(ctx, delay); sleepErr != nil {
+			return nil, errors.Join(err, sleepErr)
+		}
+	}
+}
+
+// backoff returns exponential delay with jitter for the given attempt.
+func (r RetryConfig) backoff(attempt int) time.Duration {
+	delay := r.BaseDelay << (attempt - 1)
+	if delay <= 0 || delay > r.MaxDelay {
+		delay = r.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
+
+// parseRetryAfter supports both delay-seconds and HTTP-date formats.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
@@ -0,0 +1,168 @@
+package gTranslate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func okResponse(t *testing.T, text string) *http.Response {
+	bytesBody, err := json.Marshal(Response{
+		Data: Data{
+			Translations: []Translations{{Text: text}},
+		},
+	})
+	if err != nil {
+		assert.Fail(t, "Cannot read bytes")
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(bytes.NewReader(bytesBody)),
+	}
+}
+
+func errorResponse(statusCode int, retryAfter string) *http.Response {
+	header := http.Header{}
+	if retryAfter != "" {
+		header.Set("Retry-After", retryAfter)
+	}
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     header,
+		Body:       io.NopCloser(bytes.NewReader(nil)),
+	}
+}
+
+func TestClient_Retry(t *testing.T) {
+
+	retry := RetryConfig{
+		MaxAttempts: 3,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    10 * time.Millisecond,
+	}
+
+	tests := []struct {
+		name             string
+		responses        []*http.Response
+		expectedRequests int
+		want             string
+		wantErr          bool
+	}{
+		{
+			name:             "Retry on server error",
+			responses:        []*http.Response{errorResponse(http.StatusServiceUnavailable, ""), okResponse(t, "машина")},
+			expectedRequests: 2,
+			want:             "машина",
+		},
+		{
+			name:             "Retry on 429 with Retry-After",
+			responses:        []*http.Response{errorResponse(http.StatusTooManyRequests, "0"), okResponse(t, "машина")},
+			expectedRequests: 2,
+			want:             "машина",
+		},
+		{
+			name: "Give up after max attempts",
+			responses: []*http.Response{
+				errorResponse(http.StatusBadGateway, ""),
+				errorResponse(http.StatusBadGateway, ""),
+				errorResponse(http.StatusBadGateway, ""),
+			},
+			expectedRequests: 3,
+			wantErr:          true,
+		},
+		{
+			name:             "No retry on client error",
+			responses:        []*http.Response{errorResponse(http.StatusBadRequest, "")},
+			expectedRequests: 1,
+			wantErr:          true,
+		},
+		{
+			name:             "Retry-After longer than max delay",
+			responses:        []*http.Response{errorResponse(http.StatusTooManyRequests, "60")},
+			expectedRequests: 1,
+			wantErr:          true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			c := &Client{
+				config: Config{Retry: retry},
+				client: &http.Client{
+					Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+						resp := tt.responses[requests]
+						requests++
+						return resp, nil
+					}),
+				},
+			}
+
+			got, err := c.TranslateText("car", "ru", "en")
+			assert.Equal(t, tt.expectedRequests, requests)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestClient_RateLimit(t *testing.T) {
+
+	var requests atomic.Int32
+	c := &Client{
+		limiter: newRateLimiter(50, 2),
+		client: &http.Client{
+			Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+				requests.Add(1)
+				return okResponse(t, "машина"), nil
+			}),
+		},
+	}
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.TranslateText("car", "ru", "en")
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	// Two requests go out at once, the remaining four wait 20ms each.
+	assert.Equal(t, int32(6), requests.Load())
+	assert.GreaterOrEqual(t, time.Since(start), 70*time.Millisecond)
+}
+
+func TestRateLimiter_Cancel(t *testing.T) {
+
+	l := newRateLimiter(1, 1)
+	assert.NoError(t, l.Wait(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, l.Wait(ctx), context.DeadlineExceeded)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	assert.Equal(t, 3*time.Second, parseRetryAfter("3"))
+	assert.Equal(t, time.Duration(0), parseRetryAfter(""))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("soon"))
+
+	date := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	assert.InDelta(t, float64(time.Hour), float64(parseRetryAfter(date)), float64(2*time.Second))
+}
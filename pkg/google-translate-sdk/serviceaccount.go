@@ -0,0 +1,207 @@
+package gTranslate
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	errInvalidCredentials = errors.New("Service account credentials are invalid")
+	errInvalidPrivateKey  = errors.New("Service account private key must be a PEM encoded RSA key")
+)
+
+const (
+	translationScope = "https://www.googleapis.com/auth/cloud-translation"
+	defaultTokenURI  = "https://oauth2.googleapis.com/token"
+	tokenLifetime    = time.Hour
+	// tokenExpiryDelta refreshes a token a bit before Google stops accepting it.
+	tokenExpiryDelta = time.Minute
+)
+
+// serviceAccount is the json key file downloaded from Google Cloud console.
+type serviceAccount struct {
+	Type         string `json:"type"`
+	ProjectID    string `json:"project_id"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	ClientEmail  string `json:"client_email"`
+	TokenURI     string `json:"token_uri"`
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+	TokenType   string `json:"token_type"`
+}
+
+// tokenSource issues OAuth2 access tokens for a service account and caches
+// them until shortly before they expire. It is safe for concurrent use.
+type tokenSource struct {
+	account serviceAccount
+	key     *rsa.PrivateKey
+	client  *http.Client
+	now     func() time.Time
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+func newTokenSource(credentials []byte, client *http.Client) (*tokenSource, error) {
+	var account serviceAccount
+	if err := json.Unmarshal(credentials, &account); err != nil {
+		return nil, errInvalidCredentials
+	}
+	if account.Type != "service_account" || account.ClientEmail == "" {
+		return nil, errInvalidCredentials
+	}
+	if account.TokenURI == "" {
+		account.TokenURI = defaultTokenURI
+	}
+
+	key, err := parsePrivateKey(account.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tokenSource{
+		account: account,
+		key:     key,
+		client:  client,
+		now:     time.Now,
+	}, nil
+}
+
+func parsePrivateKey(data string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, errInvalidPrivateKey
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, errInvalidPrivateKey
+		}
+		return rsaKey, nil
+	}
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errInvalidPrivateKey
+	}
+
+	return key, nil
+}
+
+// Token returns a valid access token, fetching a new one when needed.
+func (s *tokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && s.now().Before(s.expiry.Add(-tokenExpiryDelta)) {
+		return s.token, nil
+	}
+
+	token, err := s.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	s.token = token.AccessToken
+	s.expiry = s.now().Add(time.Duration(token.ExpiresIn) * time.Second)
+
+	return s.token, nil
+}
+
+// Invalidate drops the cached token, e.g. when the api rejected it.
+func (s *tokenSource) Invalidate() {
+	s.mu.Lock()
+	s.token = ""
+	s.mu.Unlock()
+}
+
+func (s *tokenSource) fetch(ctx context.Context) (*tokenResponse, error) {
+	assertion, err := s.assertion()
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+	form.Set("assertion", assertion)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.account.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, redactError(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		apiErr := parseAPIError(resp)
+		apiErr.Kind = KindInvalidKey
+		return nil, apiErr
+	}
+
+	var token tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return nil, err
+	}
+	if token.AccessToken == "" {
+		return nil, errInvalidCredentials
+	}
+
+	return &token, nil
+}
+
+// assertion builds a signed JWT used to obtain an access token.
+func (s *tokenSource) assertion() (string, error) {
+	header, err := json.Marshal(map[string]string{
+		"alg": "RS256",
+		"typ": "JWT",
+		"kid": s.account.PrivateKeyID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	iat := s.now()
+	claims, err := json.Marshal(map[string]interface{}{
+		"iss":   s.account.ClientEmail,
+		"scope": translationScope,
+		"aud":   s.account.TokenURI,
+		"iat":   iat.Unix(),
+		"exp":   iat.Add(tokenLifetime).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+
+	hash := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", err
+	}
+
+	return unsigned + "." + enc.EncodeToString(signature), nil
+}
@@ -1,28 +1,48 @@
 package gTranslate
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"net/url"
 	"path"
+	"strings"
+	"unicode/utf8"
 )
 
 var (
 	errForeighApi    = errors.New("Something wrong on the google's server side.")
 	errEmptyApiKey   = errors.New("API Key for google's api cannot be empty")
 	errNilHttpClient = errors.New("Http Client cannot be nil")
+	errBatchMismatch = errors.New("Google's api returned unexpected number of translations")
+	errEmptyResponse = errors.New("Google's api returned no translations")
 )
 
 //go:generate mockgen -source=translate.go -destination=mocks/mock.go
 
 type IClient interface {
 	TranslateText(text string, target string, source string) (string, error)
+	TranslateBatch(ctx context.Context, texts []string, target string, source string) ([]string, error)
+	// TranslateHTML translates text nodes of an html fragment keeping the markup.
+	// Elements marked with translate="no" or class="notranslate" are kept as is.
+	TranslateHTML(ctx context.Context, html string, target string, source string) (string, error)
 }
 
 const (
 	host         = "https://translation.googleapis.com"
 	translateURL = "/language/translate/v2"
+	apiKeyHeader = "X-Goog-Api-Key"
+
+	formatText = "text"
+	formatHTML = "html"
+)
+
+// Google's v2 endpoint limits for a single request and a single text in it.
+const (
+	maxBatchSize   = 128
+	maxBatchLength = 30000
+	maxTextLength  = 5000
 )
 
 type Translations struct {
@@ -39,11 +59,19 @@ type Response struct {
 
 type Config struct {
 	Key string
+	// Retry defaults to 3 attempts when MaxAttempts is zero.
+	Retry RetryConfig
+	// RateLimit is the number of requests per second allowed for the client.
+	// Zero disables throttling.
+	RateLimit float64
+	// Burst is the number of requests that can be sent at once.
+	Burst int
 }
 
 type Client struct {
-	config Config
-	client *http.Client
+	config  Config
+	client  *http.Client
+	limiter *rateLimiter
 }
 
 func NewClient(config Config, client *http.Client) (IClient, error) {
@@ -53,47 +81,152 @@ func NewClient(config Config, client *http.Client) (IClient, error) {
 	if client == nil {
 		return nil, errNilHttpClient
 	}
+	if config.Retry.MaxAttempts == 0 {
+		config.Retry = defaultRetry
+	}
+
+	var limiter *rateLimiter
+	if config.RateLimit > 0 {
+		limiter = newRateLimiter(config.RateLimit, config.Burst)
+	}
+
 	return &Client{
-		config: config,
-		client: client,
+		config:  config,
+		client:  client,
+		limiter: limiter,
 	}, nil
 }
 
 func (c *Client) TranslateText(text string, target string, source string) (string, error) {
 
-	url, err := url.ParseRequestURI(host)
+	translated, err := c.TranslateBatch(context.Background(), []string{text}, target, source)
 	if err != nil {
 		return "", err
 	}
-	url.Path = path.Join(url.Path, translateURL)
 
-	q := url.Query()
-	q.Set("model", "base")
-	q.Set("target", target)
-	q.Set("source", source)
-	q.Set("format", "text")
-	q.Set("q", text)
-	q.Set("key", c.config.Key)
-	url.RawQuery = q.Encode()
+	return translated[0], nil
+}
 
-	req, err := http.NewRequest(http.MethodGet, url.String(), nil)
+// TranslateBatch translates all texts and returns results in the same order.
+// Long texts are chunked on sentence boundaries and the chunks are sent
+// in as many requests as Google's limits require.
+func (c *Client) TranslateBatch(ctx context.Context, texts []string, target string, source string) ([]string, error) {
+	return translateChunked(ctx, texts, func(ctx context.Context, batch []string) ([]string, error) {
+		return c.translateBatch(ctx, batch, target, source, formatText)
+	})
+}
+
+// TranslateHTML sends the fragment in one piece, since cutting it could break
+// the markup.
+func (c *Client) TranslateHTML(ctx context.Context, html string, target string, source string) (string, error) {
+
+	translated, err := c.translateBatch(ctx, []string{html}, target, source, formatHTML)
 	if err != nil {
 		return "", err
 	}
 
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return "", err
+	return translated[0], nil
+}
+
+// translateChunked splits texts into chunks and batches and translates them
+// with translate, which handles a single request.
+func translateChunked(ctx context.Context, texts []string, translate func(ctx context.Context, batch []string) ([]string, error)) ([]string, error) {
+
+	var chunks []string
+	split := make([]splitText, len(texts))
+	for i, text := range texts {
+		split[i] = splitSentences(text, maxTextLength)
+		chunks = append(chunks, split[i].chunks...)
+	}
+
+	translated := make([]string, 0, len(chunks))
+	for _, batch := range splitBatch(chunks) {
+		res, err := translate(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		translated = append(translated, res...)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return "", errForeighApi
+	result := make([]string, len(texts))
+	for i := range split {
+		n := len(split[i].chunks)
+		result[i] = split[i].join(translated[:n])
+		translated = translated[n:]
 	}
 
+	return result, nil
+}
+
+func (c *Client) translateBatch(ctx context.Context, texts []string, target string, source string, format string) ([]string, error) {
+
+	u, err := url.ParseRequestURI(host)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = path.Join(u.Path, translateURL)
+
+	form := url.Values{}
+	form.Set("model", "base")
+	form.Set("target", target)
+	form.Set("source", source)
+	form.Set("format", format)
+	form["q"] = texts
+
+	body := form.Encode()
+	resp, err := c.do(ctx, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), strings.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set(apiKeyHeader, c.config.Key)
+		return req, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
 	var respData Response
 	if err := json.NewDecoder(resp.Body).Decode(&respData); err != nil {
-		return "", err
+		return nil, err
+	}
+	if len(respData.Data.Translations) == 0 {
+		return nil, errEmptyResponse
+	}
+	if len(respData.Data.Translations) != len(texts) {
+		return nil, errBatchMismatch
+	}
+
+	translated := make([]string, len(texts))
+	for i, t := range respData.Data.Translations {
+		translated[i] = t.Text
+	}
+
+	return translated, nil
+}
+
+// splitBatch groups texts into batches that fit into a single request.
+// A text longer than the request limit is sent in a batch of its own.
+func splitBatch(texts []string) [][]string {
+	var batches [][]string
+	var current []string
+	length := 0
+
+	for _, text := range texts {
+		n := utf8.RuneCountInString(text)
+		if len(current) > 0 && (len(current) == maxBatchSize || length+n > maxBatchLength) {
+			batches = append(batches, current)
+			current = nil
+			length = 0
+		}
+		current = append(current, text)
+		length += n
+	}
+	if len(current) > 0 {
+		batches = append(batches, current)
 	}
 
-	return respData.Data.Translations[0].Text, nil
+	return batches
 }
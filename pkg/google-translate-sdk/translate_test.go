@@ -2,9 +2,13 @@ package gTranslate
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -21,7 +25,8 @@ func newClientMock(t *testing.T, statusCode int, path string, body Response) *Cl
 		client: &http.Client{
 			Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
 				assert.Equal(t, path, r.URL.Path)
-				assert.Equal(t, http.MethodGet, r.Method)
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Empty(t, r.URL.RawQuery)
 
 				bytesBody, err := json.Marshal(body)
 				if err != nil {
@@ -85,7 +90,24 @@ func TestClient_TranslateText(t *testing.T) {
 					Translations: []Translations{},
 				},
 			},
-			expectedErrorMessage: errForeighApi.Error(),
+			expectedErrorMessage: "google translate api: server error (502): Bad Gateway",
+			want:                 "",
+			wantErr:              true,
+		},
+		{
+			name: "Empty translations",
+			args: args{
+				"car",
+				"en",
+				"ru",
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedResponse: Response{
+				Data: Data{
+					Translations: []Translations{},
+				},
+			},
+			expectedErrorMessage: errEmptyResponse.Error(),
 			want:                 "",
 			wantErr:              true,
 		},
@@ -131,7 +153,8 @@ func TestClient_NewClient(t *testing.T) {
 			},
 			want: &Client{
 				config: Config{
-					Key: "valid key",
+					Key:   "valid key",
+					Retry: defaultRetry,
 				},
 				client: &http.Client{},
 			},
@@ -177,3 +200,188 @@ func TestClient_NewClient(t *testing.T) {
 		})
 	}
 }
+
+func newBatchClientMock(t *testing.T, requests *int) *Client {
+	return &Client{
+		config: Config{Key: "secret"},
+		client: &http.Client{
+			Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+				assert.Equal(t, "/language/translate/v2", r.URL.Path)
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Equal(t, "secret", r.Header.Get(apiKeyHeader))
+				*requests++
+
+				if err := r.ParseForm(); err != nil {
+					assert.Fail(t, "Cannot parse form")
+				}
+				assert.LessOrEqual(t, len(r.PostForm["q"]), maxBatchSize)
+
+				var body Response
+				for _, q := range r.PostForm["q"] {
+					body.Data.Translations = append(body.Data.Translations, Translations{Text: "t:" + q})
+				}
+				bytesBody, err := json.Marshal(body)
+				if err != nil {
+					assert.Fail(t, "Cannot read bytes")
+				}
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewReader(bytesBody)),
+				}, nil
+			}),
+		},
+	}
+}
+
+func TestClient_TranslateBatch(t *testing.T) {
+
+	manyTexts := make([]string, maxBatchSize*2+1)
+	for i := range manyTexts {
+		manyTexts[i] = fmt.Sprint(i)
+	}
+	longTexts := make([]string, maxBatchLength/maxTextLength+1)
+	for i := range longTexts {
+		longTexts[i] = strings.Repeat("a", maxTextLength)
+	}
+
+	tests := []struct {
+		name             string
+		texts            []string
+		expectedRequests int
+	}{
+		{
+			name:             "Single batch",
+			texts:            []string{"car", "dog", "cat"},
+			expectedRequests: 1,
+		},
+		{
+			name:             "Split by size",
+			texts:            manyTexts,
+			expectedRequests: 3,
+		},
+		{
+			name:             "Split by length",
+			texts:            longTexts,
+			expectedRequests: 2,
+		},
+		{
+			name:             "Empty",
+			texts:            nil,
+			expectedRequests: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			c := newBatchClientMock(t, &requests)
+
+			got, err := c.TranslateBatch(context.Background(), tt.texts, "ru", "en")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedRequests, requests)
+			assert.Len(t, got, len(tt.texts))
+			for i, text := range tt.texts {
+				assert.Equal(t, "t:"+text, got[i])
+			}
+		})
+	}
+}
+
+func TestClient_APIError(t *testing.T) {
+
+	tests := []struct {
+		name         string
+		statusCode   int
+		body         string
+		expectedKind ErrorKind
+		temporary    bool
+	}{
+		{
+			name:         "Invalid key",
+			statusCode:   http.StatusBadRequest,
+			body:         `{"error":{"code":400,"message":"API key not valid. Please pass a valid API key.","status":"INVALID_ARGUMENT","details":[{"reason":"API_KEY_INVALID"}]}}`,
+			expectedKind: KindInvalidKey,
+		},
+		{
+			name:         "Quota exceeded",
+			statusCode:   http.StatusForbidden,
+			body:         `{"error":{"code":403,"message":"Daily Limit Exceeded","errors":[{"reason":"dailyLimitExceeded"}]}}`,
+			expectedKind: KindQuotaExceeded,
+			temporary:    true,
+		},
+		{
+			name:         "Rate limited",
+			statusCode:   http.StatusTooManyRequests,
+			body:         `{"error":{"code":429,"message":"Quota exceeded","status":"RESOURCE_EXHAUSTED"}}`,
+			expectedKind: KindQuotaExceeded,
+			temporary:    true,
+		},
+		{
+			name:         "Bad language",
+			statusCode:   http.StatusBadRequest,
+			body:         `{"error":{"code":400,"message":"Bad language pair: en|xx","errors":[{"reason":"badRequest"}]}}`,
+			expectedKind: KindBadLanguage,
+		},
+		{
+			name:         "Invalid target",
+			statusCode:   http.StatusBadRequest,
+			body:         `{"error":{"code":400,"message":"Invalid Value","errors":[{"reason":"invalid"}]}}`,
+			expectedKind: KindBadLanguage,
+		},
+		{
+			name:         "Text too long",
+			statusCode:   http.StatusBadRequest,
+			body:         `{"error":{"code":400,"message":"Text too long","errors":[{"reason":"invalid"}]}}`,
+			expectedKind: KindTextTooLong,
+		},
+		{
+			name:         "Server error without body",
+			statusCode:   http.StatusServiceUnavailable,
+			body:         ``,
+			expectedKind: KindServer,
+			temporary:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{
+				client: &http.Client{
+					Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+						return &http.Response{
+							StatusCode: tt.statusCode,
+							Body:       io.NopCloser(strings.NewReader(tt.body)),
+						}, nil
+					}),
+				},
+			}
+
+			_, err := c.TranslateText("car", "ru", "en")
+			var apiErr *APIError
+			if assert.True(t, errors.As(err, &apiErr)) {
+				assert.Equal(t, tt.statusCode, apiErr.StatusCode)
+				assert.Equal(t, tt.expectedKind, apiErr.Kind)
+				assert.Equal(t, tt.temporary, apiErr.Temporary())
+			}
+			assert.ErrorIs(t, err, errForeighApi)
+		})
+	}
+}
+
+func TestClient_TranslateHTML(t *testing.T) {
+
+	c := &Client{
+		client: &http.Client{
+			Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+				assert.NoError(t, r.ParseForm())
+				assert.Equal(t, "html", r.PostForm.Get("format"))
+				assert.Equal(t, []string{"<b>red</b> car"}, r.PostForm["q"])
+				return okResponse(t, "<b>красная</b> машина"), nil
+			}),
+		},
+	}
+
+	got, err := c.TranslateHTML(context.Background(), "<b>red</b> car", "ru", "en")
+	assert.NoError(t, err)
+	assert.Equal(t, "<b>красная</b> машина", got)
+}
@@ -0,0 +1,222 @@
+package gTranslate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var errEmptyProjectID = errors.New("Google cloud project id cannot be empty")
+
+const (
+	v3TranslateURL  = "/v3/projects/%s/locations/%s:translateText"
+	defaultLocation = "global"
+
+	mimeText = "text/plain"
+	mimeHTML = "text/html"
+)
+
+type V3Config struct {
+	// Credentials is the content of a service account json key file.
+	Credentials []byte
+	// ProjectID defaults to the project of the service account.
+	ProjectID string
+	// Location defaults to "global". Glossaries require a regional location.
+	Location string
+	// GlossaryID is the id of a glossary created in the same location.
+	GlossaryID string
+	// Model is a model id such as "general/nmt", empty for the default one.
+	Model string
+	// Endpoint overrides Google's api host, used in tests.
+	Endpoint string
+	// Retry defaults to 3 attempts when MaxAttempts is zero.
+	Retry RetryConfig
+	// RateLimit is the number of requests per second allowed for the client.
+	// Zero disables throttling.
+	RateLimit float64
+	// Burst is the number of requests that can be sent at once.
+	Burst int
+}
+
+type v3GlossaryConfig struct {
+	Glossary string `json:"glossary"`
+}
+
+type v3Request struct {
+	Contents           []string          `json:"contents"`
+	MimeType           string            `json:"mimeType"`
+	SourceLanguageCode string            `json:"sourceLanguageCode,omitempty"`
+	TargetLanguageCode string            `json:"targetLanguageCode"`
+	Model              string            `json:"model,omitempty"`
+	GlossaryConfig     *v3GlossaryConfig `json:"glossaryConfig,omitempty"`
+}
+
+type v3Response struct {
+	Translations         []Translations `json:"translations"`
+	GlossaryTranslations []Translations `json:"glossaryTranslations"`
+}
+
+// V3Client talks to the Cloud Translation v3 api authorized with
+// a service account.
+type V3Client struct {
+	config  V3Config
+	client  *http.Client
+	limiter *rateLimiter
+	tokens  *tokenSource
+}
+
+func NewV3Client(config V3Config, client *http.Client) (IClient, error) {
+	if client == nil {
+		return nil, errNilHttpClient
+	}
+
+	tokens, err := newTokenSource(config.Credentials, client)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.ProjectID == "" {
+		config.ProjectID = tokens.account.ProjectID
+	}
+	if config.ProjectID == "" {
+		return nil, errEmptyProjectID
+	}
+	if config.Location == "" {
+		config.Location = defaultLocation
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = host
+	}
+	if config.Retry.MaxAttempts == 0 {
+		config.Retry = defaultRetry
+	}
+
+	var limiter *rateLimiter
+	if config.RateLimit > 0 {
+		limiter = newRateLimiter(config.RateLimit, config.Burst)
+	}
+
+	return &V3Client{
+		config:  config,
+		client:  client,
+		limiter: limiter,
+		tokens:  tokens,
+	}, nil
+}
+
+func (c *V3Client) TranslateText(text string, target string, source string) (string, error) {
+
+	translated, err := c.TranslateBatch(context.Background(), []string{text}, target, source)
+	if err != nil {
+		return "", err
+	}
+
+	return translated[0], nil
+}
+
+func (c *V3Client) TranslateBatch(ctx context.Context, texts []string, target string, source string) ([]string, error) {
+	return translateChunked(ctx, texts, func(ctx context.Context, batch []string) ([]string, error) {
+		return c.translateBatch(ctx, batch, target, source, mimeText)
+	})
+}
+
+func (c *V3Client) TranslateHTML(ctx context.Context, html string, target string, source string) (string, error) {
+
+	translated, err := c.translateBatch(ctx, []string{html}, target, source, mimeHTML)
+	if err != nil {
+		return "", err
+	}
+
+	return translated[0], nil
+}
+
+func (c *V3Client) parent() string {
+	return fmt.Sprintf("projects/%s/locations/%s", c.config.ProjectID, c.config.Location)
+}
+
+func (c *V3Client) translateBatch(ctx context.Context, texts []string, target string, source string, mimeType string) ([]string, error) {
+
+	body := v3Request{
+		Contents:           texts,
+		MimeType:           mimeType,
+		SourceLanguageCode: source,
+		TargetLanguageCode: target,
+	}
+	if c.config.Model != "" {
+		body.Model = c.parent() + "/models/" + strings.TrimPrefix(c.config.Model, "/")
+	}
+	if c.config.GlossaryID != "" {
+		body.GlossaryConfig = &v3GlossaryConfig{
+			Glossary: c.parent() + "/glossaries/" + c.config.GlossaryID,
+		}
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	url := strings.TrimSuffix(c.config.Endpoint, "/") +
+		fmt.Sprintf(v3TranslateURL, c.config.ProjectID, c.config.Location)
+
+	var respData v3Response
+	if err := c.post(ctx, url, data, &respData); err != nil {
+		return nil, err
+	}
+
+	translations := respData.Translations
+	if len(respData.GlossaryTranslations) > 0 {
+		translations = respData.GlossaryTranslations
+	}
+	if len(translations) == 0 {
+		return nil, errEmptyResponse
+	}
+	if len(translations) != len(texts) {
+		return nil, errBatchMismatch
+	}
+
+	translated := make([]string, len(texts))
+	for i, t := range translations {
+		translated[i] = t.Text
+	}
+
+	return translated, nil
+}
+
+// post sends json data to url and decodes the response into out. A request
+// rejected as unauthorized is repeated once with a new access token.
+func (c *V3Client) post(ctx context.Context, url string, data []byte, out interface{}) error {
+
+	for refreshed := false; ; refreshed = true {
+		resp, err := doWithRetry(ctx, c.client, c.config.Retry, c.limiter, func(ctx context.Context) (*http.Request, error) {
+			token, err := c.tokens.Token(ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+			return req, nil
+		})
+
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized && !refreshed {
+			c.tokens.Invalidate()
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+}
@@ -0,0 +1,216 @@
+package gTranslate
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeGoogle struct {
+	key            *rsa.PrivateKey
+	tokenServer    *httptest.Server
+	translate      *httptest.Server
+	tokensIssued   atomic.Int32
+	rejectToken    atomic.Bool
+	lastRequest    v3Request
+	lastAuthHeader string
+}
+
+func newFakeGoogle(t *testing.T) *fakeGoogle {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	f := &fakeGoogle{key: key}
+
+	f.tokenServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "urn:ietf:params:oauth:grant-type:jwt-bearer", r.PostForm.Get("grant_type"))
+
+		parts := strings.Split(r.PostForm.Get("assertion"), ".")
+		require.Len(t, parts, 3)
+		signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+		require.NoError(t, err)
+		hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hash[:], signature); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		claimsJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+		require.NoError(t, err)
+		var claims map[string]interface{}
+		require.NoError(t, json.Unmarshal(claimsJSON, &claims))
+		assert.Equal(t, "bot@project.iam.gserviceaccount.com", claims["iss"])
+		assert.Equal(t, translationScope, claims["scope"])
+
+		n := f.tokensIssued.Add(1)
+		json.NewEncoder(w).Encode(tokenResponse{
+			AccessToken: "token-" + string(rune('0'+n)),
+			ExpiresIn:   3600,
+			TokenType:   "Bearer",
+		})
+	}))
+	t.Cleanup(f.tokenServer.Close)
+
+	f.translate = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v3/projects/project/locations/us-central1:translateText", r.URL.Path)
+		f.lastAuthHeader = r.Header.Get("Authorization")
+		if f.rejectToken.CompareAndSwap(true, false) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var req v3Request
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		f.lastRequest = req
+
+		var resp v3Response
+		for _, text := range req.Contents {
+			resp.Translations = append(resp.Translations, Translations{Text: "t:" + text})
+			if req.GlossaryConfig != nil {
+				resp.GlossaryTranslations = append(resp.GlossaryTranslations, Translations{Text: "g:" + text})
+			}
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(f.translate.Close)
+
+	return f
+}
+
+func (f *fakeGoogle) credentials(t *testing.T) []byte {
+	key, err := x509.MarshalPKCS8PrivateKey(f.key)
+	require.NoError(t, err)
+
+	credentials, err := json.Marshal(serviceAccount{
+		Type:         "service_account",
+		ProjectID:    "project",
+		PrivateKeyID: "key-id",
+		PrivateKey:   string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: key})),
+		ClientEmail:  "bot@project.iam.gserviceaccount.com",
+		TokenURI:     f.tokenServer.URL,
+	})
+	require.NoError(t, err)
+
+	return credentials
+}
+
+func newFakeV3Client(t *testing.T, f *fakeGoogle, config V3Config) *V3Client {
+	config.Credentials = f.credentials(t)
+	config.Location = "us-central1"
+	config.Endpoint = f.translate.URL
+
+	c, err := NewV3Client(config, http.DefaultClient)
+	require.NoError(t, err)
+
+	return c.(*V3Client)
+}
+
+func TestV3Client_TranslateBatch(t *testing.T) {
+
+	tests := []struct {
+		name             string
+		config           V3Config
+		expectedModel    string
+		expectedGlossary string
+		want             []string
+	}{
+		{
+			name: "Default model",
+			want: []string{"t:car", "t:dog"},
+		},
+		{
+			name:          "Custom model",
+			config:        V3Config{Model: "general/nmt"},
+			expectedModel: "projects/project/locations/us-central1/models/general/nmt",
+			want:          []string{"t:car", "t:dog"},
+		},
+		{
+			name:             "Glossary",
+			config:           V3Config{GlossaryID: "team"},
+			expectedGlossary: "projects/project/locations/us-central1/glossaries/team",
+			want:             []string{"g:car", "g:dog"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeGoogle(t)
+			c := newFakeV3Client(t, f, tt.config)
+
+			got, err := c.TranslateBatch(context.Background(), []string{"car", "dog"}, "ru", "en")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+
+			assert.Equal(t, "Bearer token-1", f.lastAuthHeader)
+			assert.Equal(t, "ru", f.lastRequest.TargetLanguageCode)
+			assert.Equal(t, "en", f.lastRequest.SourceLanguageCode)
+			assert.Equal(t, tt.expectedModel, f.lastRequest.Model)
+			if tt.expectedGlossary != "" {
+				assert.Equal(t, tt.expectedGlossary, f.lastRequest.GlossaryConfig.Glossary)
+			} else {
+				assert.Nil(t, f.lastRequest.GlossaryConfig)
+			}
+		})
+	}
+}
+
+func TestV3Client_TokenCache(t *testing.T) {
+
+	f := newFakeGoogle(t)
+	c := newFakeV3Client(t, f, V3Config{})
+
+	now := time.Now()
+	c.tokens.now = func() time.Time { return now }
+
+	_, err := c.TranslateText("car", "ru", "en")
+	assert.NoError(t, err)
+	_, err = c.TranslateText("dog", "ru", "en")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), f.tokensIssued.Load())
+
+	// The token is refreshed shortly before it expires.
+	now = now.Add(tokenLifetime - tokenExpiryDelta)
+	_, err = c.TranslateText("cat", "ru", "en")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), f.tokensIssued.Load())
+	assert.Equal(t, "Bearer token-2", f.lastAuthHeader)
+
+	// A rejected token is dropped and the request is repeated once.
+	f.rejectToken.Store(true)
+	got, err := c.TranslateText("cat", "ru", "en")
+	assert.NoError(t, err)
+	assert.Equal(t, "t:cat", got)
+	assert.Equal(t, int32(3), f.tokensIssued.Load())
+}
+
+func TestV3Client_NewClient(t *testing.T) {
+
+	f := newFakeGoogle(t)
+
+	_, err := NewV3Client(V3Config{Credentials: []byte(`{"type":"authorized_user"}`)}, http.DefaultClient)
+	assert.ErrorIs(t, err, errInvalidCredentials)
+
+	_, err = NewV3Client(V3Config{Credentials: f.credentials(t)}, nil)
+	assert.ErrorIs(t, err, errNilHttpClient)
+
+	got, err := NewV3Client(V3Config{Credentials: f.credentials(t)}, http.DefaultClient)
+	assert.NoError(t, err)
+	assert.Equal(t, "project", got.(*V3Client).config.ProjectID)
+	assert.Equal(t, defaultLocation, got.(*V3Client).config.Location)
+}
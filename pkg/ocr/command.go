@@ -0,0 +1,78 @@
+package ocr
+
+import (
+	"context"
+	"os"
+
+	"github.com/maxik12233/english-helper-telegrambot/pkg/cmdrunner"
+)
+
+// DefaultCommand runs tesseract printing the text to stdout.
+const DefaultCommand = "tesseract {file} stdout -l {lang3}"
+
+// tesseractLangs maps ISO 639-1 codes to the ISO 639-2 codes tesseract uses
+// to name its language data.
+var tesseractLangs = map[string]string{
+	"en": "eng",
+	"ru": "rus",
+	"uk": "ukr",
+	"de": "deu",
+	"fr": "fra",
+	"es": "spa",
+	"it": "ita",
+	"pt": "por",
+	"pl": "pol",
+	"nl": "nld",
+	"tr": "tur",
+	"zh": "chi_sim",
+	"ja": "jpn",
+	"ko": "kor",
+}
+
+// CommandProvider runs a local OCR engine that prints the text to stdout.
+// {file} in its arguments is replaced with the path to the image, {lang}
+// with the language and {lang3} with the language in tesseract's notation.
+type CommandProvider struct {
+	command cmdrunner.Command
+}
+
+func NewCommandProvider(line string) (Provider, error) {
+	command, err := cmdrunner.Parse(line)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CommandProvider{command: command}, nil
+}
+
+func (p *CommandProvider) Recognize(ctx context.Context, image []byte, lang string) (string, error) {
+	if len(image) == 0 {
+		return "", ErrEmptyImage
+	}
+
+	file, err := os.CreateTemp("", "photo-*.jpg")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.Write(image)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	lang3, ok := tesseractLangs[lang]
+	if !ok {
+		lang3 = lang
+	}
+
+	out, err := p.command.Run(ctx, map[string]string{"file": file.Name(), "lang": lang, "lang3": lang3}, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return cleanText(string(out))
+}
@@ -0,0 +1,83 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+)
+
+type recognizeResponse struct {
+	Text  string `json:"text"`
+	Error string `json:"error"`
+}
+
+// HTTPProvider posts the image in the "file" field and the language in the
+// "lang" field of a multipart form to a recognition server, which responds
+// with {"text": ...}.
+type HTTPProvider struct {
+	url    string
+	client *http.Client
+}
+
+func NewHTTPProvider(url string, client *http.Client) (Provider, error) {
+	if url == "" {
+		return nil, errEmptyURL
+	}
+	if client == nil {
+		return nil, errNilHttpClient
+	}
+
+	return &HTTPProvider{
+		url:    url,
+		client: client,
+	}, nil
+}
+
+func (p *HTTPProvider) Recognize(ctx context.Context, image []byte, lang string) (string, error) {
+	if len(image) == 0 {
+		return "", ErrEmptyImage
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "photo.jpg")
+	if err != nil {
+		return "", err
+	}
+	if _, err := part.Write(image); err != nil {
+		return "", err
+	}
+	form.WriteField("lang", lang)
+	if err := form.Close(); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, &body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var respData recognizeResponse
+	decodeErr := json.NewDecoder(resp.Body).Decode(&respData)
+	if resp.StatusCode != http.StatusOK {
+		if respData.Error != "" {
+			return "", fmt.Errorf("recognition server responded with %v: %v", resp.Status, respData.Error)
+		}
+		return "", fmt.Errorf("recognition server responded with %v", resp.Status)
+	}
+	if decodeErr != nil {
+		return "", decodeErr
+	}
+
+	return cleanText(respData.Text)
+}
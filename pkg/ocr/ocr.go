@@ -0,0 +1,35 @@
+// Package ocr recognizes text on photos.
+package ocr
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+var (
+	ErrEmptyImage    = errors.New("Image to recognize cannot be empty")
+	ErrNoText        = errors.New("No text was recognized on the image")
+	errNilHttpClient = errors.New("Http Client cannot be nil")
+	errEmptyURL      = errors.New("URL of the recognition server cannot be empty")
+)
+
+// Provider recognizes text in the language lang (e.g. "en") on an image.
+type Provider interface {
+	Recognize(ctx context.Context, image []byte, lang string) (string, error)
+}
+
+// cleanText trims recognized lines and drops empty ones, which OCR engines
+// produce a lot of.
+func cleanText(text string) (string, error) {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return "", ErrNoText
+	}
+	return strings.Join(lines, "\n"), nil
+}
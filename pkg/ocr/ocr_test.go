@@ -0,0 +1,79 @@
+package ocr
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCleanText(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		text    string
+		want    string
+		wantErr error
+	}{
+		{
+			name: "Empty lines are dropped",
+			text: "\n  MENU \n\n soup   of the day\n\f",
+			want: "MENU\nsoup of the day",
+		},
+		{
+			name:    "Nothing recognized",
+			text:    " \n\f\n",
+			wantErr: ErrNoText,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cleanText(tt.text)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCommandProvider(t *testing.T) {
+	// echo stands in for tesseract, showing the language passed to it
+	provider, err := NewCommandProvider("echo {lang3}")
+	require.NoError(t, err)
+
+	text, err := provider.Recognize(context.Background(), []byte("image"), "ru")
+	require.NoError(t, err)
+	assert.Equal(t, "rus", text)
+
+	_, err = provider.Recognize(context.Background(), nil, "ru")
+	assert.Equal(t, ErrEmptyImage, err)
+}
+
+func TestHTTPProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(recognizeResponse{Error: "no file"})
+			return
+		}
+		image, _ := io.ReadAll(file)
+		json.NewEncoder(w).Encode(recognizeResponse{Text: r.FormValue("lang") + "\n\n" + string(image)})
+	}))
+	defer server.Close()
+
+	provider, err := NewHTTPProvider(server.URL, server.Client())
+	require.NoError(t, err)
+
+	text, err := provider.Recognize(context.Background(), []byte("EXIT"), "en")
+	require.NoError(t, err)
+	assert.Equal(t, "en\nEXIT", text)
+
+	_, err = NewHTTPProvider("", server.Client())
+	assert.Equal(t, errEmptyURL, err)
+}
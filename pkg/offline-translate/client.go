@@ -0,0 +1,150 @@
+package offlineTranslate
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"unicode"
+
+	gTranslate "github.com/maxik12233/english-helper-telegrambot/pkg/google-translate-sdk"
+)
+
+var ErrNotFound = errors.New("No translation found in the offline dictionary")
+
+type pair struct {
+	source string
+	target string
+}
+
+// Client translates words and phrases from bilingual dictionaries loaded
+// into memory. It needs no network and implements gTranslate.IClient.
+type Client struct {
+	mu    sync.RWMutex
+	dicts map[pair]*trie
+}
+
+func NewClient() *Client {
+	return &Client{dicts: make(map[pair]*trie)}
+}
+
+var _ gTranslate.IClient = (*Client)(nil)
+
+// Add adds a translation of word from source to target language.
+func (c *Client) Add(source string, target string, word string, translations ...string) {
+	word = normalize(word)
+	if word == "" || len(translations) == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p := pair{source: source, target: target}
+	dict := c.dicts[p]
+	if dict == nil {
+		dict = newTrie()
+		c.dicts[p] = dict
+	}
+	dict.Insert(word, translations...)
+}
+
+func (c *Client) dict(source string, target string) *trie {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.dicts[pair{source: source, target: target}]
+}
+
+// Lookup returns all known translations of a single word or phrase.
+func (c *Client) Lookup(text string, target string, source string) []string {
+	dict := c.dict(source, target)
+	if dict == nil {
+		return nil
+	}
+	return dict.Get(normalize(text))
+}
+
+// Suggest returns up to limit dictionary words starting with prefix.
+func (c *Client) Suggest(prefix string, source string, target string, limit int) []string {
+	dict := c.dict(source, target)
+	if dict == nil {
+		return nil
+	}
+	return dict.WithPrefix(normalize(prefix), limit)
+}
+
+// TranslateText translates a known phrase as a whole. Otherwise every word is
+// translated separately and unknown words are kept as is.
+func (c *Client) TranslateText(text string, target string, source string) (string, error) {
+	dict := c.dict(source, target)
+	if dict == nil {
+		return "", ErrNotFound
+	}
+
+	if translations := dict.Get(normalize(text)); len(translations) > 0 {
+		return translations[0], nil
+	}
+
+	found := false
+	var sb strings.Builder
+	var word []rune
+	flush := func() {
+		if len(word) == 0 {
+			return
+		}
+		if translations := dict.Get(normalize(string(word))); len(translations) > 0 {
+			sb.WriteString(translations[0])
+			found = true
+		} else {
+			sb.WriteString(string(word))
+		}
+		word = word[:0]
+	}
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\'' || r == '-' {
+			word = append(word, r)
+			continue
+		}
+		flush()
+		sb.WriteRune(r)
+	}
+	flush()
+
+	if !found {
+		return "", ErrNotFound
+	}
+
+	return sb.String(), nil
+}
+
+// TranslateBatch keeps the original text of the texts it has no translation
+// for. ErrNotFound is returned only if none of the texts were translated.
+func (c *Client) TranslateBatch(ctx context.Context, texts []string, target string, source string) ([]string, error) {
+	result := make([]string, len(texts))
+	found := false
+	for i, text := range texts {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		translated, err := c.TranslateText(text, target, source)
+		switch {
+		case errors.Is(err, ErrNotFound):
+			result[i] = text
+		case err != nil:
+			return nil, err
+		default:
+			result[i] = translated
+			found = true
+		}
+	}
+	if len(texts) > 0 && !found {
+		return nil, ErrNotFound
+	}
+
+	return result, nil
+}
+
+func normalize(text string) string {
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
@@ -0,0 +1,125 @@
+package offlineTranslate
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const tsv = `# en -> ru
+car	машина	автомобиль
+red	красный
+red car	красная машина
+carpet	ковёр
+card	карта
+`
+
+func newTestClient(t *testing.T) *Client {
+	c := NewClient()
+	assert.NoError(t, c.ReadTSV(strings.NewReader(tsv), "en", "ru"))
+	return c
+}
+
+func TestClient_TranslateText(t *testing.T) {
+
+	c := newTestClient(t)
+
+	tests := []struct {
+		name    string
+		text    string
+		target  string
+		source  string
+		want    string
+		wantErr bool
+	}{
+		{name: "Word", text: "Car", target: "ru", source: "en", want: "машина"},
+		{name: "Phrase", text: "red  car", target: "ru", source: "en", want: "красная машина"},
+		{name: "Word by word", text: "My red card!", target: "ru", source: "en", want: "My красный карта!"},
+		{name: "Reverse direction", text: "автомобиль", target: "en", source: "ru", want: "car"},
+		{name: "Unknown word", text: "dog", target: "ru", source: "en", wantErr: true},
+		{name: "Unknown pair", text: "car", target: "de", source: "en", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.TranslateText(tt.text, tt.target, tt.source)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrNotFound)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+
+	batch, err := c.TranslateBatch(context.Background(), []string{"car", "red"}, "ru", "en")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"машина", "красный"}, batch)
+
+	// Unknown texts are kept as they are
+	batch, err = c.TranslateBatch(context.Background(), []string{"car", "dog", "red"}, "ru", "en")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"машина", "dog", "красный"}, batch)
+
+	_, err = c.TranslateBatch(context.Background(), []string{"dog", "cat"}, "ru", "en")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestClient_Suggest(t *testing.T) {
+
+	c := newTestClient(t)
+
+	assert.Equal(t, []string{"car", "card", "carpet"}, c.Suggest("car", "en", "ru", 5))
+	assert.Equal(t, []string{"car", "card"}, c.Suggest("CA", "en", "ru", 2))
+	assert.Empty(t, c.Suggest("x", "en", "ru", 5))
+	assert.Equal(t, []string{"машина", "автомобиль"}, c.Lookup("car", "ru", "en"))
+}
+
+func TestClient_LoadStarDict(t *testing.T) {
+
+	dir := t.TempDir()
+	base := filepath.Join(dir, "en-ru")
+
+	var idx, dict bytes.Buffer
+	for _, entry := range [][2]string{
+		{"apple", "<b>яблоко</b><br>фрукт"},
+		{"dog", "собака"},
+	} {
+		idx.WriteString(entry[0])
+		idx.WriteByte(0)
+		binary.Write(&idx, binary.BigEndian, uint32(dict.Len()))
+		binary.Write(&idx, binary.BigEndian, uint32(len(entry[1])))
+		dict.WriteString(entry[1])
+	}
+
+	ifo := "StarDict's dict ifo file\nversion=2.4.2\nwordcount=2\nsametypesequence=h\n"
+	assert.NoError(t, os.WriteFile(base+".ifo", []byte(ifo), 0644))
+	assert.NoError(t, os.WriteFile(base+".idx", idx.Bytes(), 0644))
+	assert.NoError(t, os.WriteFile(base+".dict", dict.Bytes(), 0644))
+
+	c := NewClient()
+	assert.NoError(t, c.LoadStarDict(base+".ifo", "en", "ru"))
+
+	got, err := c.TranslateText("apple", "ru", "en")
+	assert.NoError(t, err)
+	assert.Equal(t, "яблоко", got)
+
+	got, err = c.TranslateText("dog", "ru", "en")
+	assert.NoError(t, err)
+	assert.Equal(t, "собака", got)
+}
+
+func TestClient_TranslateHTML(t *testing.T) {
+
+	c := newTestClient(t)
+
+	got, err := c.TranslateHTML(context.Background(), `<b>red</b> car <code class="notranslate">car</code>`, "ru", "en")
+	assert.NoError(t, err)
+	assert.Equal(t, `<b>красный</b> машина <code class="notranslate">car</code>`, got)
+}
@@ -0,0 +1,69 @@
+package offlineTranslate
+
+import (
+	"context"
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	htmlTagRe     = regexp.MustCompile(`<(/?)([a-zA-Z][a-zA-Z0-9-]*)([^>]*)>`)
+	notranslateRe = regexp.MustCompile(`translate\s*=\s*"no"|class\s*=\s*"[^"]*\bnotranslate\b[^"]*"`)
+)
+
+// TranslateHTML translates text between tags and keeps the markup. Text of
+// elements marked with translate="no" or class="notranslate" is kept as is.
+func (c *Client) TranslateHTML(ctx context.Context, fragment string, target string, source string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	found := false
+	var sb strings.Builder
+	// names of open elements that must not be translated
+	var skip []string
+
+	translate := func(text string) {
+		if len(skip) > 0 || strings.TrimSpace(text) == "" {
+			sb.WriteString(text)
+			return
+		}
+		translated, err := c.TranslateText(html.UnescapeString(text), target, source)
+		if err != nil {
+			sb.WriteString(text)
+			return
+		}
+		found = true
+		// Keep spaces around the text, whole phrase lookups drop them.
+		trimmed := strings.TrimLeftFunc(text, unicode.IsSpace)
+		sb.WriteString(text[:len(text)-len(trimmed)])
+		sb.WriteString(html.EscapeString(translated))
+		sb.WriteString(trimmed[len(strings.TrimRightFunc(trimmed, unicode.IsSpace)):])
+	}
+
+	last := 0
+	for _, m := range htmlTagRe.FindAllStringSubmatchIndex(fragment, -1) {
+		translate(fragment[last:m[0]])
+		sb.WriteString(fragment[m[0]:m[1]])
+		last = m[1]
+
+		closing := fragment[m[2]:m[3]] == "/"
+		name := strings.ToLower(fragment[m[4]:m[5]])
+		attrs := fragment[m[6]:m[7]]
+		switch {
+		case closing && len(skip) > 0 && skip[len(skip)-1] == name:
+			skip = skip[:len(skip)-1]
+		case !closing && !strings.HasSuffix(attrs, "/") && (len(skip) > 0 || notranslateRe.MatchString(attrs)):
+			skip = append(skip, name)
+		}
+	}
+	translate(fragment[last:])
+
+	if !found {
+		return "", ErrNotFound
+	}
+
+	return sb.String(), nil
+}
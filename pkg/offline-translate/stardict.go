@@ -0,0 +1,169 @@
+package offlineTranslate
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var errInvalidStarDict = errors.New("Invalid StarDict dictionary")
+
+var tagRe = regexp.MustCompile(`<[^>]*>`)
+
+type starDictInfo struct {
+	sameTypeSequence string
+	offsetBits       int
+}
+
+// LoadStarDict adds a StarDict dictionary. ifoPath is the path to the .ifo
+// file, the .idx and .dict (or .dict.dz) files must be next to it.
+func (c *Client) LoadStarDict(ifoPath string, source string, target string) error {
+	base := strings.TrimSuffix(ifoPath, ".ifo")
+
+	info, err := readStarDictInfo(ifoPath)
+	if err != nil {
+		return err
+	}
+
+	idx, err := os.ReadFile(base + ".idx")
+	if err != nil {
+		return err
+	}
+
+	dict, err := readStarDictData(base)
+	if err != nil {
+		return err
+	}
+
+	for len(idx) > 0 {
+		end := bytes.IndexByte(idx, 0)
+		if end < 0 {
+			return errInvalidStarDict
+		}
+		word := string(idx[:end])
+		idx = idx[end+1:]
+
+		var offset, size uint64
+		if info.offsetBits == 64 {
+			if len(idx) < 12 {
+				return errInvalidStarDict
+			}
+			offset = binary.BigEndian.Uint64(idx)
+			size = uint64(binary.BigEndian.Uint32(idx[8:]))
+			idx = idx[12:]
+		} else {
+			if len(idx) < 8 {
+				return errInvalidStarDict
+			}
+			offset = uint64(binary.BigEndian.Uint32(idx))
+			size = uint64(binary.BigEndian.Uint32(idx[4:]))
+			idx = idx[8:]
+		}
+		if offset+size > uint64(len(dict)) {
+			return errInvalidStarDict
+		}
+
+		if translation := parseDefinition(dict[offset:offset+size], info.sameTypeSequence); translation != "" {
+			c.Add(source, target, word, translation)
+		}
+	}
+
+	return nil
+}
+
+func readStarDictInfo(path string) (*starDictInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info := &starDictInfo{offsetBits: 32}
+	scanner := bufio.NewScanner(f)
+	if !scanner.Scan() || !strings.HasPrefix(scanner.Text(), "StarDict's dict ifo file") {
+		return nil, errInvalidStarDict
+	}
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "sametypesequence":
+			info.sameTypeSequence = value
+		case "idxoffsetbits":
+			if value == "64" {
+				info.offsetBits = 64
+			}
+		}
+	}
+
+	return info, scanner.Err()
+}
+
+// readStarDictData reads the plain or dictzip compressed definitions file.
+func readStarDictData(base string) ([]byte, error) {
+	if data, err := os.ReadFile(base + ".dict"); err == nil {
+		return data, nil
+	}
+
+	f, err := os.Open(base + ".dict.dz")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	return io.ReadAll(zr)
+}
+
+// parseDefinition extracts the first line of a textual definition.
+func parseDefinition(data []byte, sameTypeSequence string) string {
+	var kind byte
+	if sameTypeSequence != "" {
+		kind = sameTypeSequence[0]
+		if len(sameTypeSequence) > 1 {
+			// Fields but the last one are null terminated.
+			if end := bytes.IndexByte(data, 0); end >= 0 {
+				data = data[:end]
+			}
+		}
+	} else {
+		if len(data) == 0 {
+			return ""
+		}
+		kind = data[0]
+		data = data[1:]
+		if end := bytes.IndexByte(data, 0); end >= 0 {
+			data = data[:end]
+		}
+	}
+
+	text := string(data)
+	switch kind {
+	case 'm', 'l', 't', 'y':
+	case 'h', 'g', 'x':
+		text = strings.NewReplacer("<br>", "\n", "<br/>", "\n", "<br />", "\n").Replace(text)
+		text = tagRe.ReplaceAllString(text, "")
+	default:
+		return ""
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
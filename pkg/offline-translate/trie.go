@@ -0,0 +1,113 @@
+package offlineTranslate
+
+import "sort"
+
+// trie maps words to their translations and supports prefix lookups.
+type trie struct {
+	root *trieNode
+	size int
+}
+
+type trieNode struct {
+	children     map[rune]*trieNode
+	translations []string
+}
+
+func newTrie() *trie {
+	return &trie{root: &trieNode{}}
+}
+
+func (t *trie) Insert(word string, translations ...string) {
+	node := t.root
+	for _, r := range word {
+		if node.children == nil {
+			node.children = make(map[rune]*trieNode)
+		}
+		child := node.children[r]
+		if child == nil {
+			child = &trieNode{}
+			node.children[r] = child
+		}
+		node = child
+	}
+
+	if len(node.translations) == 0 {
+		t.size++
+	}
+	for _, tr := range translations {
+		if !contains(node.translations, tr) {
+			node.translations = append(node.translations, tr)
+		}
+	}
+}
+
+func (t *trie) find(prefix string) *trieNode {
+	node := t.root
+	for _, r := range prefix {
+		node = node.children[r]
+		if node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+// Get returns translations of the word.
+func (t *trie) Get(word string) []string {
+	node := t.find(word)
+	if node == nil {
+		return nil
+	}
+	return node.translations
+}
+
+// WithPrefix returns up to limit words starting with prefix, shortest and
+// then alphabetically first.
+func (t *trie) WithPrefix(prefix string, limit int) []string {
+	node := t.find(prefix)
+	if node == nil || limit <= 0 {
+		return nil
+	}
+
+	var words []string
+	// Breadth-first walk yields shorter words first.
+	type item struct {
+		node *trieNode
+		word []rune
+	}
+	queue := []item{{node: node, word: []rune(prefix)}}
+	for len(queue) > 0 && len(words) < limit {
+		var next []item
+		var level []string
+		for _, it := range queue {
+			if len(it.node.translations) > 0 {
+				level = append(level, string(it.word))
+			}
+			for r, child := range it.node.children {
+				word := append(append([]rune{}, it.word...), r)
+				next = append(next, item{node: child, word: word})
+			}
+		}
+		sort.Strings(level)
+		words = append(words, level...)
+		queue = next
+	}
+	if len(words) > limit {
+		words = words[:limit]
+	}
+
+	return words
+}
+
+func (t *trie) Len() int {
+	return t.size
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package offlineTranslate
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+)
+
+// LoadTSV adds a bilingual dictionary from a tab separated file. Every line
+// holds a source word followed by one or more translations, lines starting
+// with # are comments. Translations are indexed in both directions.
+func (c *Client) LoadTSV(path string, source string, target string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return c.ReadTSV(f, source, target)
+}
+
+func (c *Client) ReadTSV(r io.Reader, source string, target string) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) < 2 {
+			continue
+		}
+		word := strings.TrimSpace(fields[0])
+		var translations []string
+		for _, f := range fields[1:] {
+			if f = strings.TrimSpace(f); f != "" {
+				translations = append(translations, f)
+			}
+		}
+
+		c.Add(source, target, word, translations...)
+		for _, tr := range translations {
+			c.Add(target, source, tr, word)
+		}
+	}
+
+	return scanner.Err()
+}
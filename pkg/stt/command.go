@@ -0,0 +1,90 @@
+package stt
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/maxik12233/english-helper-telegrambot/pkg/cmdrunner"
+)
+
+// DefaultCommand runs whisper.cpp printing plain text without timestamps.
+// whisper.cpp reads wav only, so it is given the voice message converted
+// with ffmpeg.
+const DefaultCommand = "whisper-cli -m models/ggml-base.bin -l {lang} -nt -np -f {wav}"
+
+// ConvertCommand converts the audio of voice messages, OGG/Opus, to the
+// 16 kHz mono wav speech recognizers expect.
+const ConvertCommand = "ffmpeg -loglevel error -y -i {file} -ar 16000 -ac 1 -c:a pcm_s16le {wav}"
+
+// CommandProvider runs a local recognizer that prints the transcript to
+// stdout. {file} in its arguments is replaced with the path to the audio
+// and {lang} with its language. {wav} is replaced with the path to the audio
+// converted to wav by ConvertCommand.
+type CommandProvider struct {
+	command cmdrunner.Command
+	// convert runs before the recognizer if it reads {wav}
+	convert cmdrunner.Command
+}
+
+func NewCommandProvider(line string) (Provider, error) {
+	command, err := cmdrunner.Parse(line)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &CommandProvider{command: command}
+	if strings.Contains(line, "{wav}") {
+		if p.convert, err = cmdrunner.Parse(ConvertCommand); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+func (p *CommandProvider) Transcribe(ctx context.Context, audio []byte, lang string) (string, error) {
+	if len(audio) == 0 {
+		return "", ErrEmptyAudio
+	}
+
+	file, err := os.CreateTemp("", "voice-*.ogg")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.Write(audio)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	vars := map[string]string{"file": file.Name(), "lang": lang}
+	if p.convert.Name != "" {
+		vars["wav"] = file.Name() + ".wav"
+		defer os.Remove(vars["wav"])
+		if _, err := p.convert.Run(ctx, vars, nil); err != nil {
+			return "", fmt.Errorf("converting audio: %w", err)
+		}
+	}
+
+	out, err := p.command.Run(ctx, vars, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return transcript(string(out))
+}
+
+// transcript joins recognized lines into a single text.
+func transcript(out string) (string, error) {
+	text := strings.Join(strings.Fields(out), " ")
+	if text == "" {
+		return "", ErrEmptyTranscript
+	}
+	return text, nil
+}
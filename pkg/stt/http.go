@@ -0,0 +1,84 @@
+package stt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+)
+
+type inferenceResponse struct {
+	Text  string `json:"text"`
+	Error string `json:"error"`
+}
+
+// HTTPProvider sends audio to a whisper.cpp style server: a multipart form
+// with the audio in the "file" field is posted to its /inference endpoint,
+// which responds with {"text": ...}.
+type HTTPProvider struct {
+	url    string
+	client *http.Client
+}
+
+func NewHTTPProvider(url string, client *http.Client) (Provider, error) {
+	if url == "" {
+		return nil, errEmptyURL
+	}
+	if client == nil {
+		return nil, errNilHttpClient
+	}
+
+	return &HTTPProvider{
+		url:    url,
+		client: client,
+	}, nil
+}
+
+func (p *HTTPProvider) Transcribe(ctx context.Context, audio []byte, lang string) (string, error) {
+	if len(audio) == 0 {
+		return "", ErrEmptyAudio
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "voice.ogg")
+	if err != nil {
+		return "", err
+	}
+	if _, err := part.Write(audio); err != nil {
+		return "", err
+	}
+	form.WriteField("language", lang)
+	form.WriteField("response_format", "json")
+	if err := form.Close(); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, &body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var respData inferenceResponse
+	decodeErr := json.NewDecoder(resp.Body).Decode(&respData)
+	if resp.StatusCode != http.StatusOK {
+		if respData.Error != "" {
+			return "", fmt.Errorf("transcription server responded with %v: %v", resp.Status, respData.Error)
+		}
+		return "", fmt.Errorf("transcription server responded with %v", resp.Status)
+	}
+	if decodeErr != nil {
+		return "", decodeErr
+	}
+
+	return transcript(respData.Text)
+}
@@ -0,0 +1,21 @@
+// Package stt transcribes voice messages.
+package stt
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrEmptyAudio      = errors.New("Audio to transcribe cannot be empty")
+	ErrEmptyTranscript = errors.New("Nothing was recognized in the audio")
+	errNilHttpClient   = errors.New("Http Client cannot be nil")
+	errEmptyURL        = errors.New("URL of the transcription server cannot be empty")
+)
+
+// Provider transcribes audio spoken in the language lang (e.g. "en").
+// Telegram voice messages are OGG/Opus, providers that need another format
+// must convert it themselves.
+type Provider interface {
+	Transcribe(ctx context.Context, audio []byte, lang string) (string, error)
+}
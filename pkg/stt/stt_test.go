@@ -0,0 +1,76 @@
+package stt
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/maxik12233/english-helper-telegrambot/pkg/cmdrunner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandProvider(t *testing.T) {
+	// cat prints the audio file itself, standing in for a recognizer
+	provider, err := NewCommandProvider("cat {file}")
+	require.NoError(t, err)
+
+	text, err := provider.Transcribe(context.Background(), []byte(" red\n car \n"), "en")
+	require.NoError(t, err)
+	assert.Equal(t, "red car", text)
+
+	_, err = provider.Transcribe(context.Background(), []byte("\n"), "en")
+	assert.Equal(t, ErrEmptyTranscript, err)
+
+	_, err = provider.Transcribe(context.Background(), nil, "en")
+	assert.Equal(t, ErrEmptyAudio, err)
+}
+
+func TestCommandProvider_Convert(t *testing.T) {
+	provider, err := NewCommandProvider("cat {wav}")
+	require.NoError(t, err)
+	p := provider.(*CommandProvider)
+	assert.Equal(t, "ffmpeg", p.convert.Name)
+
+	// cp stands in for ffmpeg
+	p.convert = cmdrunner.Command{Name: "cp", Args: []string{"{file}", "{wav}"}}
+	text, err := provider.Transcribe(context.Background(), []byte("red car"), "en")
+	require.NoError(t, err)
+	assert.Equal(t, "red car", text)
+
+	p.convert = cmdrunner.Command{Name: "false"}
+	_, err = provider.Transcribe(context.Background(), []byte("red car"), "en")
+	assert.Error(t, err)
+
+	// Recognizers reading the audio itself need no conversion
+	provider, err = NewCommandProvider("cat {file}")
+	require.NoError(t, err)
+	assert.Empty(t, provider.(*CommandProvider).convert.Name)
+}
+
+func TestHTTPProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(inferenceResponse{Error: "no file"})
+			return
+		}
+		audio, _ := io.ReadAll(file)
+		json.NewEncoder(w).Encode(inferenceResponse{Text: r.FormValue("language") + ": " + string(audio) + "\n"})
+	}))
+	defer server.Close()
+
+	provider, err := NewHTTPProvider(server.URL, server.Client())
+	require.NoError(t, err)
+
+	text, err := provider.Transcribe(context.Background(), []byte("red car"), "en")
+	require.NoError(t, err)
+	assert.Equal(t, "en: red car", text)
+
+	_, err = NewHTTPProvider("", server.Client())
+	assert.Equal(t, errEmptyURL, err)
+}
@@ -3,9 +3,12 @@ package telegram
 import (
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/maxik12233/english-helper-telegrambot/pkg/db"
+	"github.com/maxik12233/english-helper-telegrambot/pkg/dictionary"
 	gTranslate "github.com/maxik12233/english-helper-telegrambot/pkg/google-translate-sdk"
 	"github.com/maxik12233/english-helper-telegrambot/pkg/logger"
-	"go.mongodb.org/mongo-driver/mongo"
+	"github.com/maxik12233/english-helper-telegrambot/pkg/ocr"
+	"github.com/maxik12233/english-helper-telegrambot/pkg/stt"
+	"github.com/maxik12233/english-helper-telegrambot/pkg/tts"
 	"go.uber.org/zap"
 )
 
@@ -13,14 +16,56 @@ type Bot struct {
 	bot              *tgbotapi.BotAPI
 	repo             db.IRepository
 	translateService gTranslate.IClient
+	dictionary       dictionary.Provider
+	speech           tts.Provider
+	transcriber      stt.Provider
+	recognizer       ocr.Provider
+	inline           *inlineState
 }
 
-func NewBot(botAPI *tgbotapi.BotAPI, repo db.IRepository, translateService gTranslate.IClient) Bot {
-	return Bot{
+// Option configures optional services of the bot.
+type Option func(b *Bot)
+
+// WithDictionary enables detailed replies for single words.
+func WithDictionary(provider dictionary.Provider) Option {
+	return func(b *Bot) {
+		b.dictionary = provider
+	}
+}
+
+// WithSpeech adds a button sending the pronunciation of the source text to
+// translations.
+func WithSpeech(provider tts.Provider) Option {
+	return func(b *Bot) {
+		b.speech = provider
+	}
+}
+
+// WithTranscriber enables translation of voice messages.
+func WithTranscriber(provider stt.Provider) Option {
+	return func(b *Bot) {
+		b.transcriber = provider
+	}
+}
+
+// WithRecognizer enables translation of text on photos.
+func WithRecognizer(provider ocr.Provider) Option {
+	return func(b *Bot) {
+		b.recognizer = provider
+	}
+}
+
+func NewBot(botAPI *tgbotapi.BotAPI, repo db.IRepository, translateService gTranslate.IClient, opts ...Option) Bot {
+	b := Bot{
 		bot:              botAPI,
 		repo:             repo,
 		translateService: translateService,
+		inline:           newInlineState(),
 	}
+	for _, opt := range opts {
+		opt(&b)
+	}
+	return b
 }
 
 func (b *Bot) Start() {
@@ -38,6 +83,23 @@ func (b *Bot) CreateUpdateChan() tgbotapi.UpdatesChannel {
 func (b *Bot) handleUpdates(updates tgbotapi.UpdatesChannel) {
 	log := logger.GetLogger().With(zap.String("place", "Inside handleUpdates"))
 	for update := range updates {
+		if update.InlineQuery != nil {
+			b.handleInlineQuery(update.InlineQuery)
+			continue
+		}
+
+		if update.ChosenInlineResult != nil {
+			if err := b.handleChosenInlineResult(update.ChosenInlineResult); err != nil {
+				log.Error("Error while saving chosen inline result.", zap.Error(err))
+			}
+			continue
+		}
+
+		if update.CallbackQuery != nil {
+			b.handleCallbackQuery(update.CallbackQuery)
+			continue
+		}
+
 		if update.Message == nil {
 			log.Info("Updated message in nil")
 			continue
@@ -62,18 +124,32 @@ func (b *Bot) handleUpdates(updates tgbotapi.UpdatesChannel) {
 }
 
 func (b *Bot) GetOrCreateUserConfig(userid uint) (*db.Config, error) {
+	return b.repo.GetOrCreateConfig(defaultConfig(userid))
+}
+
+// maxConfigUpdates limits attempts to update a config that keeps being
+// changed concurrently.
+const maxConfigUpdates = 3
 
-	cfg, err := b.repo.GetConfig(userid)
-	if err == mongo.ErrNoDocuments {
-		cfg = &defaultCfg
-		cfg.UserID = userid
-		err := b.repo.CreateConfig(cfg)
+// updateUserConfig applies change to the latest config of the user and saves
+// it. If the config was changed meanwhile, change is applied to the fresh
+// config again.
+func (b *Bot) updateUserConfig(userid uint, change func(cfg *db.Config)) (*db.Config, error) {
+	for attempt := 1; ; attempt++ {
+		cfg, err := b.GetOrCreateUserConfig(userid)
+		if err != nil {
+			return nil, err
+		}
+
+		change(cfg)
+		err = b.repo.UpdateConfig(cfg)
+		if err == db.ErrConflict && attempt < maxConfigUpdates {
+			continue
+		}
 		if err != nil {
 			return nil, err
 		}
-	} else if err != nil {
-		return nil, err
-	}
 
-	return cfg, nil
+		return cfg, nil
+	}
 }
@@ -0,0 +1,115 @@
+package telegram
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sync"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/maxik12233/english-helper-telegrambot/pkg/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testAPI is a fake Telegram server which records the called methods and
+// answers every request with success.
+type testAPI struct {
+	mu      sync.Mutex
+	methods []string
+}
+
+func (a *testAPI) called() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]string(nil), a.methods...)
+}
+
+func newTestAPI(t *testing.T) (*tgbotapi.BotAPI, *testAPI) {
+	api := &testAPI{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method := path.Base(r.URL.Path)
+		api.mu.Lock()
+		api.methods = append(api.methods, method)
+		api.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		switch method {
+		case "getMe":
+			w.Write([]byte(`{"ok":true,"result":{"id":100,"is_bot":true,"first_name":"Helper","username":"helper_bot"}}`))
+		case "answerCallbackQuery":
+			w.Write([]byte(`{"ok":true,"result":true}`))
+		default:
+			w.Write([]byte(`{"ok":true,"result":{"message_id":2,"chat":{"id":1,"type":"private"}}}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	botAPI, err := tgbotapi.NewBotAPIWithClient("token", server.URL+"/bot%s/%s", server.Client())
+	require.NoError(t, err)
+
+	api.methods = nil
+	return botAPI, api
+}
+
+// conflictingRepo fails the first conflicts updates as if the config was
+// changed concurrently.
+type conflictingRepo struct {
+	db.IRepository
+	conflicts int
+	updates   int
+}
+
+func (r *conflictingRepo) UpdateConfig(cfg *db.Config) error {
+	r.updates++
+	if r.updates <= r.conflicts {
+		return db.ErrConflict
+	}
+	return r.IRepository.UpdateConfig(cfg)
+}
+
+func TestUpdateUserConfig(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		conflicts   int
+		wantErr     error
+		wantUpdates int
+	}{
+		{name: "No conflicts", conflicts: 0, wantUpdates: 1},
+		{name: "Conflict is retried", conflicts: 2, wantUpdates: 3},
+		{name: "Too many conflicts", conflicts: maxConfigUpdates, wantErr: db.ErrConflict, wantUpdates: maxConfigUpdates},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &conflictingRepo{IRepository: db.NewMemoryRepo(), conflicts: tt.conflicts}
+			b := &Bot{repo: repo}
+
+			cfg, err := b.updateUserConfig(1, func(cfg *db.Config) {
+				cfg.Mode = modeTranslate
+			})
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantUpdates, repo.updates)
+			if tt.wantErr == nil {
+				assert.Equal(t, modeTranslate, cfg.Mode)
+			}
+		})
+	}
+}
+
+func TestGetOrCreateUserConfig(t *testing.T) {
+	b := &Bot{repo: db.NewMemoryRepo()}
+
+	first, err := b.GetOrCreateUserConfig(1)
+	require.NoError(t, err)
+	second, err := b.GetOrCreateUserConfig(2)
+	require.NoError(t, err)
+
+	// New users must not share the defaults
+	first.Mode = modeRepeat
+	assert.Equal(t, modeDefault, second.Mode)
+	assert.Equal(t, modeDefault, defaultConfig(3).Mode)
+	assert.EqualValues(t, 2, second.UserID)
+}
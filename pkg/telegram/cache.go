@@ -0,0 +1,102 @@
+package telegram
+
+import (
+	"container/list"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// translationCache keeps recent translations in memory, evicting the least
+// recently used ones when full. It is safe for concurrent use.
+type translationCache struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	capacity int
+	items    map[string]*list.Element
+	order    *list.List
+	now      func() time.Time
+}
+
+type cacheItem struct {
+	key     string
+	value   string
+	expires time.Time
+}
+
+func newTranslationCache(capacity int, ttl time.Duration) *translationCache {
+	return &translationCache{
+		ttl:      ttl,
+		capacity: capacity,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+		now:      time.Now,
+	}
+}
+
+// cacheKey includes the user, since translations depend on user's glossary.
+func cacheKey(userid uint, source string, target string, text string) string {
+	return userCacheKeyPrefix(userid) + fmt.Sprintf("%v|%v|%v", source, target, text)
+}
+
+// userCacheKeyPrefix starts the keys of all translations of the user.
+func userCacheKeyPrefix(userid uint) string {
+	return fmt.Sprintf("%d|", userid)
+}
+
+func (c *translationCache) Get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return "", false
+	}
+	item := el.Value.(*cacheItem)
+	if c.now().After(item.expires) {
+		c.order.Remove(el)
+		delete(c.items, key)
+		return "", false
+	}
+	c.order.MoveToFront(el)
+
+	return item.value, true
+}
+
+func (c *translationCache) Set(key string, value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		item := el.Value.(*cacheItem)
+		item.value = value
+		item.expires = c.now().Add(c.ttl)
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&cacheItem{
+		key:     key,
+		value:   value,
+		expires: c.now().Add(c.ttl),
+	})
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheItem).key)
+	}
+}
+
+// DeletePrefix removes the items with keys starting with prefix.
+func (c *translationCache) DeletePrefix(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, el := range c.items {
+		if strings.HasPrefix(key, prefix) {
+			c.order.Remove(el)
+			delete(c.items, key)
+		}
+	}
+}
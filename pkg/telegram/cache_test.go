@@ -0,0 +1,55 @@
+package telegram
+
+import (
+	"testing"
+	"time"
+
+	"github.com/maxik12233/english-helper-telegrambot/pkg/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslationCache(t *testing.T) {
+
+	now := time.Now()
+	c := newTranslationCache(2, time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.Set("car", "машина")
+	c.Set("dog", "собака")
+	got, ok := c.Get("car")
+	assert.True(t, ok)
+	assert.Equal(t, "машина", got)
+
+	// "dog" is the least recently used one
+	c.Set("cat", "кошка")
+	_, ok = c.Get("dog")
+	assert.False(t, ok)
+	_, ok = c.Get("car")
+	assert.True(t, ok)
+
+	now = now.Add(2 * time.Minute)
+	_, ok = c.Get("cat")
+	assert.False(t, ok)
+}
+
+func TestGlossaryChangeForgetsTranslations(t *testing.T) {
+
+	b := &Bot{repo: db.NewMemoryRepo(), inline: newInlineState()}
+	cfg := &db.Config{UserID: 1, Source: "en", Target: "ru"}
+	b.inline.cache.Set(cacheKey(1, "en", "ru", "pull request"), "запрос на вытягивание")
+	b.inline.cache.Set(cacheKey(12, "en", "ru", "pull request"), "запрос на вытягивание")
+
+	_, err := b.addGlossaryEntry(cfg, "pull request = пулл-реквест")
+	assert.NoError(t, err)
+	_, ok := b.inline.cache.Get(cacheKey(1, "en", "ru", "pull request"))
+	assert.False(t, ok)
+	// Translations of other users are kept
+	_, ok = b.inline.cache.Get(cacheKey(12, "en", "ru", "pull request"))
+	assert.True(t, ok)
+
+	b.inline.cache.Set(cacheKey(1, "en", "ru", "pull request"), "пулл-реквест")
+	_, err = b.removeGlossaryEntry(cfg, "pull request")
+	assert.NoError(t, err)
+	_, ok = b.inline.cache.Get(cacheKey(1, "en", "ru", "pull request"))
+	assert.False(t, ok)
+}
@@ -0,0 +1,64 @@
+package telegram
+
+import (
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/maxik12233/english-helper-telegrambot/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// maxCallbackData is Telegram's limit for callback data of a button in bytes.
+const maxCallbackData = 64
+
+// callbackData joins the action and its arguments with ':'. ok is false if
+// the data does not fit into a button.
+func callbackData(action string, args ...string) (data string, ok bool) {
+	data = strings.Join(append([]string{action}, args...), ":")
+	return data, len(data) <= maxCallbackData
+}
+
+// parseCallbackData splits data into the action and at most n arguments, the
+// last one may contain ':'.
+func parseCallbackData(data string, n int) (action string, args []string) {
+	parts := strings.SplitN(data, ":", n+1)
+	return parts[0], parts[1:]
+}
+
+func (b *Bot) handleCallbackQuery(query *tgbotapi.CallbackQuery) {
+	log := logger.GetLogger()
+
+	if query.Message == nil {
+		// Buttons of inline messages are not used
+		b.answerCallback(query, "")
+		return
+	}
+
+	var err error
+	action, _, _ := strings.Cut(query.Data, ":")
+	switch action {
+	case callbackPronounce:
+		err = b.handlePronounceCallback(query)
+	case callbackSaveWord:
+		err = b.handleSaveWordCallback(query)
+	case callbackForgetMe:
+		err = b.handleForgetMeCallback(query)
+	default:
+		b.answerCallback(query, "")
+	}
+
+	if err != nil {
+		log.Error("Error while handling a button.", zap.Error(err), zap.String("data", query.Data))
+		b.answerCallback(query, err.Error())
+	}
+}
+
+// answerCallback stops the loading animation of the button, showing text
+// to the user if it is not empty.
+func (b *Bot) answerCallback(query *tgbotapi.CallbackQuery, text string) {
+	log := logger.GetLogger()
+
+	if _, err := b.bot.Request(tgbotapi.NewCallback(query.ID, text)); err != nil {
+		log.Error("Error while answering callback query", zap.Error(err))
+	}
+}
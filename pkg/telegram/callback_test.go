@@ -0,0 +1,117 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/maxik12233/english-helper-telegrambot/pkg/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallbackData(t *testing.T) {
+
+	data, ok := callbackData(callbackPronounce, "en", "red: car")
+	assert.True(t, ok)
+	assert.Equal(t, "pronounce:en:red: car", data)
+
+	action, args := parseCallbackData(data, 2)
+	assert.Equal(t, callbackPronounce, action)
+	assert.Equal(t, []string{"en", "red: car"}, args)
+
+	_, ok = callbackData(callbackPronounce, "en", strings.Repeat("a", maxCallbackData))
+	assert.False(t, ok)
+}
+
+// speechStub pronounces every text as audio, or as the text itself if audio
+// is not set.
+type speechStub struct {
+	texts []string
+	audio []byte
+}
+
+func (s *speechStub) Synthesize(ctx context.Context, text string, lang string) ([]byte, error) {
+	s.texts = append(s.texts, lang+":"+text)
+	if s.audio != nil {
+		return s.audio, nil
+	}
+	return []byte(text), nil
+}
+
+// opusAudio starts like an OGG/Opus stream.
+var opusAudio = []byte("OggS\x00\x02\x00\x00\x00\x00\x00\x00\x00\x00\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01\x13OpusHead\x01\x01")
+
+func callbackQuery(data string) *tgbotapi.CallbackQuery {
+	return &tgbotapi.CallbackQuery{
+		ID:   "1",
+		From: &tgbotapi.User{ID: 1},
+		Message: &tgbotapi.Message{
+			MessageID: 2,
+			Chat:      &tgbotapi.Chat{ID: 1, Type: "private"},
+		},
+		Data: data,
+	}
+}
+
+func TestHandleCallbackQuery(t *testing.T) {
+
+	botAPI, api := newTestAPI(t)
+	speech := &speechStub{audio: opusAudio}
+	b := &Bot{bot: botAPI, repo: db.NewMemoryRepo(), speech: speech}
+
+	data, ok := callbackData(callbackPronounce, "en", "red: car")
+	require.True(t, ok)
+	b.handleCallbackQuery(callbackQuery(data))
+	assert.Equal(t, []string{"en:red: car"}, speech.texts)
+	assert.Equal(t, []string{"sendVoice", "answerCallbackQuery"}, api.called())
+
+	// Unknown buttons are only answered
+	b.handleCallbackQuery(callbackQuery("unknown:en:car"))
+	assert.Len(t, speech.texts, 1)
+	assert.Equal(t, []string{"sendVoice", "answerCallbackQuery", "answerCallbackQuery"}, api.called())
+}
+
+func TestPronunciationMessage(t *testing.T) {
+
+	message := &tgbotapi.Message{MessageID: 2, Chat: &tgbotapi.Chat{ID: 1}}
+	wav := append([]byte("RIFF\x24\x00\x00\x00WAVEfmt "), make([]byte, 32)...)
+
+	tests := []struct {
+		name     string
+		audio    []byte
+		wantFile string
+		isVoice  bool
+	}{
+		{name: "Opus", audio: opusAudio, wantFile: "pronunciation.ogg", isVoice: true},
+		{name: "Wav", audio: wav, wantFile: "pronunciation.wav"},
+		{name: "Mp3", audio: append([]byte("ID3\x03\x00"), make([]byte, 32)...), wantFile: "pronunciation.mp3"},
+		{name: "OGG without Opus", audio: []byte("OggS\x00\x02 vorbis"), wantFile: "pronunciation.ogg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			switch msg := pronunciationMessage(message, tt.audio).(type) {
+			case tgbotapi.VoiceConfig:
+				assert.True(t, tt.isVoice)
+				assert.Equal(t, tt.wantFile, msg.File.(tgbotapi.FileBytes).Name)
+				assert.Equal(t, 2, msg.ReplyToMessageID)
+			case tgbotapi.AudioConfig:
+				assert.False(t, tt.isVoice)
+				assert.Equal(t, tt.wantFile, msg.File.(tgbotapi.FileBytes).Name)
+				assert.Equal(t, 2, msg.ReplyToMessageID)
+			default:
+				t.Fatalf("unexpected message %T", msg)
+			}
+		})
+	}
+
+	// Wav of the default synthesizer is sent as audio
+	botAPI, api := newTestAPI(t)
+	b := &Bot{bot: botAPI, repo: db.NewMemoryRepo(), speech: &speechStub{audio: wav}}
+	data, ok := callbackData(callbackPronounce, "en", "car")
+	require.True(t, ok)
+	b.handleCallbackQuery(callbackQuery(data))
+	assert.Equal(t, []string{"sendAudio", "answerCallbackQuery"}, api.called())
+}
@@ -0,0 +1,167 @@
+package telegram
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/maxik12233/english-helper-telegrambot/pkg/db"
+)
+
+const (
+	challengePoints = 1
+	leaderboardSize = 10
+
+	leaderboardAllTime = "all"
+)
+
+// weekOf returns the ISO week of t, e.g. 2023-W07. Leaderboards are reset
+// every week.
+func weekOf(t time.Time) string {
+	year, week := t.ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
+
+func normalizeAnswer(text string) string {
+	text = strings.ToLower(strings.TrimSpace(text))
+	text = strings.TrimRight(text, ".!?")
+	return strings.Join(strings.Fields(text), " ")
+}
+
+func isCorrectAnswer(answer string, expected string) bool {
+	return normalizeAnswer(answer) != "" && normalizeAnswer(answer) == normalizeAnswer(expected)
+}
+
+func displayName(user *tgbotapi.User) string {
+	if user.UserName != "" {
+		return "@" + user.UserName
+	}
+	return strings.TrimSpace(user.FirstName + " " + user.LastName)
+}
+
+// handleChallengeCommand posts a random word from the chat's vocabulary.
+// An unsolved challenge is revealed and replaced with a new one.
+func (b *Bot) handleChallengeCommand(message *tgbotapi.Message) (*tgbotapi.Message, error) {
+
+	trnsl, err := b.repo.GetRandomChatTranslation(message.Chat.ID)
+	if err == db.ErrNotFound {
+		return nil, ErrNoVocabulary
+	} else if err != nil {
+		return nil, ErrInternal
+	}
+
+	var text string
+	previous, err := b.repo.GetChallenge(message.Chat.ID)
+	if err == nil {
+		text = fmt.Sprintf("Nobody guessed \"%v\" - it is \"%v\".\n\n", previous.Word, previous.Answer)
+	} else if err != db.ErrNotFound {
+		return nil, ErrInternal
+	}
+	text += fmt.Sprintf("Translate: %v\nReply to this message with your answer.", trnsl.SourceText)
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	botmsg, err := b.bot.Send(msg)
+	if err != nil {
+		return nil, ErrSending
+	}
+
+	if err := b.repo.SaveChallenge(&db.Challenge{
+		ChatID:    message.Chat.ID,
+		MessageID: botmsg.MessageID,
+		Word:      trnsl.SourceText,
+		Answer:    trnsl.TargetText,
+	}); err != nil {
+		return nil, ErrInternal
+	}
+
+	return &botmsg, nil
+}
+
+// activeChallenge returns the challenge the message replies to, if any.
+func (b *Bot) activeChallenge(message *tgbotapi.Message) (*db.Challenge, error) {
+	if !b.isFromMe(message.ReplyToMessage) {
+		return nil, nil
+	}
+
+	challenge, err := b.repo.GetChallenge(message.Chat.ID)
+	if err == db.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if challenge.MessageID != message.ReplyToMessage.MessageID {
+		return nil, nil
+	}
+
+	return challenge, nil
+}
+
+// handleChallengeAnswer awards points for the first correct answer.
+func (b *Bot) handleChallengeAnswer(message *tgbotapi.Message, challenge *db.Challenge) (*tgbotapi.Message, error) {
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, "Incorrect.")
+	msg.ReplyToMessageID = message.MessageID
+
+	if isCorrectAnswer(message.Text, challenge.Answer) {
+		won, err := b.repo.DeleteChallenge(challenge.ChatID, challenge.MessageID)
+		if err != nil {
+			return nil, ErrInternal
+		}
+		if !won {
+			// Someone else has answered first
+			return nil, nil
+		}
+
+		if err := b.repo.AddScore(&db.Score{
+			ChatID:   message.Chat.ID,
+			UserID:   uint(message.From.ID),
+			Username: displayName(message.From),
+			Week:     weekOf(time.Now()),
+			Points:   challengePoints,
+		}); err != nil {
+			return nil, ErrInternal
+		}
+
+		msg.Text = fmt.Sprintf("Excellent! %v gets %d point. Send /challenge for the next word.", displayName(message.From), challengePoints)
+	}
+
+	botmsg, err := b.bot.Send(msg)
+	if err != nil {
+		return nil, ErrSending
+	}
+
+	return &botmsg, nil
+}
+
+// handleTopCommand shows the leaderboard of the chat for the current week.
+// "/top all" shows the leaderboard for all time.
+func (b *Bot) handleTopCommand(message *tgbotapi.Message) (*tgbotapi.Message, error) {
+
+	week, title := weekOf(time.Now()), "Top of the week"
+	if strings.TrimSpace(message.CommandArguments()) == leaderboardAllTime {
+		week, title = "", "Top of all time"
+	}
+
+	scores, err := b.repo.GetLeaderboard(message.Chat.ID, week, leaderboardSize)
+	if err != nil {
+		return nil, ErrInternal
+	}
+
+	text := "Nobody has scored yet. Send /challenge to start."
+	if len(scores) > 0 {
+		lines := []string{title + ":"}
+		for i, score := range scores {
+			lines = append(lines, fmt.Sprintf("%d. %v - %d", i+1, score.Username, score.Points))
+		}
+		text = strings.Join(lines, "\n")
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	botmsg, err := b.bot.Send(msg)
+	if err != nil {
+		return nil, ErrSending
+	}
+
+	return &botmsg, nil
+}
@@ -0,0 +1,55 @@
+package telegram
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWeekOf(t *testing.T) {
+
+	tests := []struct {
+		name string
+		time time.Time
+		want string
+	}{
+		{
+			name: "Middle of the year",
+			time: time.Date(2023, time.February, 15, 12, 0, 0, 0, time.UTC),
+			want: "2023-W07",
+		},
+		{
+			name: "First days of January belong to the last week of the previous year",
+			time: time.Date(2021, time.January, 2, 12, 0, 0, 0, time.UTC),
+			want: "2020-W53",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, weekOf(tt.time))
+		})
+	}
+}
+
+func TestIsCorrectAnswer(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		answer   string
+		expected string
+		want     bool
+	}{
+		{name: "Same text", answer: "машина", expected: "машина", want: true},
+		{name: "Case, spaces and punctuation", answer: "  Красная   Машина! ", expected: "красная машина", want: true},
+		{name: "Different text", answer: "автомобиль", expected: "машина", want: false},
+		{name: "Empty answer", answer: " ", expected: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isCorrectAnswer(tt.answer, tt.expected))
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package telegram
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode/utf16"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// content is the text to translate and where it comes from.
+type content struct {
+	text     string
+	entities []tgbotapi.MessageEntity
+	// messageID is the message holding the text, the reply is sent to it
+	messageID int
+	// origin names the sender of a forwarded message
+	origin string
+	// voice is transcribed to get the text if set
+	voice *tgbotapi.Voice
+	// photo without a caption is recognized to get the text if set
+	photo *tgbotapi.PhotoSize
+	// quote is set when the original text is repeated in the reply, e.g. for
+	// captions, forwarded posts and replied-to messages
+	quote bool
+}
+
+// contentOf takes the text or the caption of a message. Voice messages and
+// photos are recognized later, when the language of the text is known.
+func contentOf(message *tgbotapi.Message) content {
+	c := content{
+		text:      message.Text,
+		entities:  message.Entities,
+		messageID: message.MessageID,
+	}
+	if c.text == "" && message.Caption != "" {
+		c.text = message.Caption
+		c.entities = message.CaptionEntities
+		c.quote = true
+	}
+
+	switch {
+	case message.ForwardFromChat != nil:
+		c.origin = message.ForwardFromChat.Title
+		if c.origin == "" {
+			c.origin = "@" + message.ForwardFromChat.UserName
+		}
+	case message.ForwardFrom != nil:
+		c.origin = strings.TrimSpace(message.ForwardFrom.FirstName + " " + message.ForwardFrom.LastName)
+	case message.ForwardSenderName != "":
+		c.origin = message.ForwardSenderName
+	}
+	if c.origin != "" {
+		c.quote = true
+	}
+	c.voice = message.Voice
+	if c.text == "" && len(message.Photo) > 0 {
+		c.photo = largestPhoto(message.Photo)
+	}
+
+	return c
+}
+
+// hasMedia reports whether the text of c can be taken from its voice
+// message or photo.
+func (b *Bot) hasMedia(c content) bool {
+	return (c.voice != nil && b.transcriber != nil) || (c.photo != nil && b.recognizer != nil)
+}
+
+// maxMessageLength is the length limit of a message text after parsing its
+// markup, counted in UTF-16 code units.
+const maxMessageLength = 4096
+
+// textLength counts text in UTF-16 code units as Telegram does.
+func textLength(text string) int {
+	return len(utf16.Encode([]rune(text)))
+}
+
+// quoteHTML renders the original text to be put above its translation. The
+// text is shortened so that the quote takes at most room characters of the
+// message, without room for the text the quote is dropped.
+func (c content) quoteHTML(room int) string {
+	if !c.quote {
+		return ""
+	}
+
+	var sb strings.Builder
+	if c.origin != "" {
+		header := fmt.Sprintf("Forwarded from %v\n", c.origin)
+		room -= textLength(header)
+		sb.WriteString(fmt.Sprintf("<i>Forwarded from %v</i>\n", html.EscapeString(c.origin)))
+	}
+	// The line break after the quote
+	room--
+	if room <= textLength(ellipsis) {
+		return ""
+	}
+	if textLength(c.text) > room {
+		c = c.truncated(room - 1)
+		c.text += ellipsis
+	}
+	sb.WriteString("<blockquote>")
+	sb.WriteString(sanitizeHTML(entitiesToHTML(c.text, c.entities)))
+	sb.WriteString("</blockquote>\n")
+
+	return sb.String()
+}
+
+const ellipsis = "…"
+
+// truncateText shortens text to at most room UTF-16 code units ending it
+// with an ellipsis, without room for any of it the text is dropped.
+func truncateText(text string, room int) string {
+	if textLength(text) <= room {
+		return text
+	}
+	if room <= textLength(ellipsis) {
+		return ""
+	}
+	return content{text: text}.truncated(room-textLength(ellipsis)).text + ellipsis
+}
+
+// truncated keeps the first length UTF-16 code units of the text and the
+// parts of the entities within them.
+func (c content) truncated(length int) content {
+	units := utf16.Encode([]rune(c.text))
+	if length >= len(units) {
+		return c
+	}
+	// A surrogate pair is not split: the last unit kept is not its first half
+	if length > 0 && units[length-1] >= 0xd800 && units[length-1] < 0xdc00 {
+		length--
+	}
+
+	var entities []tgbotapi.MessageEntity
+	for _, e := range c.entities {
+		if e.Offset >= length {
+			continue
+		}
+		if e.Offset+e.Length > length {
+			e.Length = length - e.Offset
+		}
+		entities = append(entities, e)
+	}
+
+	c.text = string(utf16.Decode(units[:length]))
+	c.entities = entities
+	return c
+}
+
+// handleTranslateCommand translates the command's argument or, when sent as
+// a reply, the replied-to message.
+func (b *Bot) handleTranslateCommand(message *tgbotapi.Message) (*tgbotapi.Message, error) {
+
+	var c content
+	switch {
+	case message.ReplyToMessage != nil:
+		c = contentOf(message.ReplyToMessage)
+		c.quote = true
+	default:
+		c = content{
+			text:      strings.TrimSpace(message.CommandArguments()),
+			messageID: message.MessageID,
+		}
+	}
+
+	return b.translateContent(message, c)
+}
@@ -0,0 +1,77 @@
+package telegram
+
+import (
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContent_QuoteHTML(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		content content
+		room    int
+		want    string
+	}{
+		{
+			name:    "Not quoted",
+			content: content{text: "red car"},
+			room:    maxMessageLength,
+			want:    "",
+		},
+		{
+			name: "Fits",
+			content: content{
+				text:     "red car",
+				entities: []tgbotapi.MessageEntity{{Type: "bold", Offset: 4, Length: 3}},
+				origin:   "News",
+				quote:    true,
+			},
+			room: maxMessageLength,
+			want: "<i>Forwarded from News</i>\n<blockquote>red <b>car</b></blockquote>\n",
+		},
+		{
+			name: "Truncated",
+			content: content{
+				text:     "red car is fast",
+				entities: []tgbotapi.MessageEntity{{Type: "bold", Offset: 4, Length: 3}, {Type: "italic", Offset: 11, Length: 4}},
+				quote:    true,
+			},
+			// The line break, "red c" and the ellipsis
+			room: 7,
+			want: "<blockquote>red <b>c</b>…</blockquote>\n",
+		},
+		{
+			name:    "Surrogate pair is not split",
+			content: content{text: "a😀b", quote: true},
+			room:    4,
+			want:    "<blockquote>a…</blockquote>\n",
+		},
+		{
+			name:    "No room",
+			content: content{text: "red car", origin: "News", quote: true},
+			room:    20,
+			want:    "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.content.quoteHTML(tt.room))
+		})
+	}
+
+	long := content{text: strings.Repeat("word ", 1000), quote: true}
+	quote := long.quoteHTML(maxMessageLength - 100)
+	assert.Equal(t, maxMessageLength-100, textLength(htmlToText(quote)))
+}
+
+func TestTruncateText(t *testing.T) {
+	assert.Equal(t, "red car", truncateText("red car", 7))
+	assert.Equal(t, "red…", truncateText("red car", 4))
+	assert.Equal(t, "", truncateText("red car", 1))
+	assert.Equal(t, "", truncateText("red car", -5))
+}
@@ -0,0 +1,80 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/maxik12233/english-helper-telegrambot/pkg/db"
+	"github.com/maxik12233/english-helper-telegrambot/pkg/dictionary"
+	"github.com/maxik12233/english-helper-telegrambot/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	maxEntries      = 3
+	maxTranslations = 5
+	maxMeanings     = 3
+	maxExamples     = 1
+)
+
+// lookupWord returns formatted dictionary entries when text is a single word
+// known to the dictionary, and empty string otherwise.
+func (b *Bot) lookupWord(text string, cfg *db.Config) string {
+	log := logger.GetLogger()
+
+	if b.dictionary == nil || !dictionary.IsWord(text) {
+		return ""
+	}
+
+	entries, err := b.dictionary.Lookup(text, cfg.Source, cfg.Target)
+	if err != nil {
+		if !errors.Is(err, dictionary.ErrNotFound) {
+			log.Error("Error while looking up a word", zap.Error(err))
+		}
+		return ""
+	}
+
+	return formatEntries(entries)
+}
+
+func formatEntries(entries []dictionary.Entry) string {
+	var sb strings.Builder
+
+	for i, e := range entries {
+		if i == maxEntries {
+			break
+		}
+		if i > 0 {
+			sb.WriteString("\n\n")
+		}
+		sb.WriteString(e.Word)
+		if e.Transcription != "" {
+			sb.WriteString(" " + e.Transcription)
+		}
+		if e.PartOfSpeech != "" {
+			sb.WriteString(fmt.Sprintf(" (%v)", e.PartOfSpeech))
+		}
+		if translations := e.Translations; len(translations) > 0 {
+			if len(translations) > maxTranslations {
+				translations = translations[:maxTranslations]
+			}
+			sb.WriteString("\n" + strings.Join(translations, ", "))
+		}
+
+		for j, m := range e.Meanings {
+			if j == maxMeanings {
+				break
+			}
+			sb.WriteString(fmt.Sprintf("\n%d. %v", j+1, m.Text))
+			for k, ex := range m.Examples {
+				if k == maxExamples {
+					break
+				}
+				sb.WriteString(fmt.Sprintf("\n   \"%v\"", ex))
+			}
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,27 @@
+package telegram
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/maxik12233/english-helper-telegrambot/pkg/dictionary"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatEntries(t *testing.T) {
+
+	var translations []string
+	for i := 0; i < 30; i++ {
+		translations = append(translations, fmt.Sprintf("t%d", i))
+	}
+	var entries []dictionary.Entry
+	for i := 0; i < 10; i++ {
+		entries = append(entries, dictionary.Entry{Word: "run", PartOfSpeech: fmt.Sprintf("pos%d", i), Translations: translations})
+	}
+
+	got := formatEntries(entries)
+	assert.Equal(t, maxEntries, strings.Count(got, "run ("))
+	assert.Contains(t, got, "\nt0, t1, t2, t3, t4\n")
+	assert.NotContains(t, got, "t5")
+}
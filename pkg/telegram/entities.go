@@ -0,0 +1,186 @@
+package telegram
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Tags for entities sent to the translator. Code and entities such as links
+// and mentions are marked so they are not translated.
+var entityTags = map[string]string{
+	"bold":          "b",
+	"italic":        "i",
+	"underline":     "u",
+	"strikethrough": "s",
+	"spoiler":       "tg-spoiler",
+	"blockquote":    "blockquote",
+	"code":          "code",
+	"pre":           "pre",
+	"text_link":     "a",
+	"text_mention":  "a",
+	"mention":       "span",
+	"hashtag":       "span",
+	"cashtag":       "span",
+	"bot_command":   "span",
+	"url":           "span",
+	"email":         "span",
+	"phone_number":  "span",
+}
+
+// formattingEntities change how a text looks, other entities are only
+// highlighted by clients.
+var formattingEntities = map[string]bool{
+	"bold":          true,
+	"italic":        true,
+	"underline":     true,
+	"strikethrough": true,
+	"spoiler":       true,
+	"blockquote":    true,
+	"code":          true,
+	"pre":           true,
+	"text_link":     true,
+	"text_mention":  true,
+}
+
+// Tags supported by Telegram's HTML parse mode.
+var allowedTags = map[string]string{
+	"b":          "b",
+	"strong":     "b",
+	"i":          "i",
+	"em":         "i",
+	"u":          "u",
+	"ins":        "u",
+	"s":          "s",
+	"strike":     "s",
+	"del":        "s",
+	"tg-spoiler": "tg-spoiler",
+	"blockquote": "blockquote",
+	"code":       "code",
+	"pre":        "pre",
+	"a":          "a",
+}
+
+var (
+	tagRe  = regexp.MustCompile(`<(/?)([a-zA-Z][a-zA-Z0-9-]*)([^>]*)>`)
+	hrefRe = regexp.MustCompile(`href\s*=\s*"([^"]*)"`)
+)
+
+func hasFormatting(entities []tgbotapi.MessageEntity) bool {
+	for _, e := range entities {
+		if formattingEntities[e.Type] {
+			return true
+		}
+	}
+	return false
+}
+
+func openTag(e tgbotapi.MessageEntity) string {
+	tag := entityTags[e.Type]
+	switch e.Type {
+	case "code", "pre", "mention", "hashtag", "cashtag", "bot_command", "url", "email", "phone_number":
+		return fmt.Sprintf(`<%v class="notranslate">`, tag)
+	case "text_link":
+		return fmt.Sprintf(`<a href="%v">`, html.EscapeString(e.URL))
+	case "text_mention":
+		if e.User != nil {
+			return fmt.Sprintf(`<a href="tg://user?id=%d">`, e.User.ID)
+		}
+		return "<a>"
+	}
+	return "<" + tag + ">"
+}
+
+// entitiesToHTML renders text with its entities as html. Entity offsets are
+// counted in UTF-16 code units as Telegram does.
+func entitiesToHTML(text string, entities []tgbotapi.MessageEntity) string {
+	var known []tgbotapi.MessageEntity
+	for _, e := range entities {
+		if entityTags[e.Type] != "" && e.Length > 0 {
+			known = append(known, e)
+		}
+	}
+	// Outer entities first: by offset, then the longest.
+	sort.SliceStable(known, func(i, j int) bool {
+		if known[i].Offset != known[j].Offset {
+			return known[i].Offset < known[j].Offset
+		}
+		return known[i].Length > known[j].Length
+	})
+
+	var sb strings.Builder
+	var open []tgbotapi.MessageEntity
+	next := 0
+	pos := 0
+
+	step := func() {
+		// Entities may overlap without nesting: the tags opened after the
+		// first ending one are closed with it and reopened.
+		first := len(open)
+		for i := len(open) - 1; i >= 0; i-- {
+			if open[i].Offset+open[i].Length <= pos {
+				first = i
+			}
+		}
+		for i := len(open) - 1; i >= first; i-- {
+			sb.WriteString("</" + entityTags[open[i].Type] + ">")
+		}
+		closed := open[first:]
+		open = open[:first]
+		for _, e := range closed {
+			if e.Offset+e.Length > pos {
+				sb.WriteString(openTag(e))
+				open = append(open, e)
+			}
+		}
+		for next < len(known) && known[next].Offset <= pos {
+			sb.WriteString(openTag(known[next]))
+			open = append(open, known[next])
+			next++
+		}
+	}
+
+	for _, r := range text {
+		step()
+		sb.WriteString(html.EscapeString(string(r)))
+		pos += len(utf16.Encode([]rune{r}))
+	}
+	step()
+	for i := len(open) - 1; i >= 0; i-- {
+		sb.WriteString("</" + entityTags[open[i].Type] + ">")
+	}
+
+	return sb.String()
+}
+
+// sanitizeHTML keeps only the markup Telegram accepts in HTML parse mode.
+func sanitizeHTML(text string) string {
+	return tagRe.ReplaceAllStringFunc(text, func(tag string) string {
+		m := tagRe.FindStringSubmatch(tag)
+		closing, name, attrs := m[1] == "/", strings.ToLower(m[2]), m[3]
+
+		allowed, ok := allowedTags[name]
+		if !ok {
+			return ""
+		}
+		if closing {
+			return "</" + allowed + ">"
+		}
+		if allowed == "a" {
+			if href := hrefRe.FindStringSubmatch(attrs); href != nil {
+				return fmt.Sprintf(`<a href="%v">`, href[1])
+			}
+		}
+		return "<" + allowed + ">"
+	})
+}
+
+// htmlToText strips the markup leaving the plain text.
+func htmlToText(text string) string {
+	return html.UnescapeString(tagRe.ReplaceAllString(text, ""))
+}
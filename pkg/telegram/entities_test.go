@@ -0,0 +1,68 @@
+package telegram
+
+import (
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEntitiesToHTML(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		text     string
+		entities []tgbotapi.MessageEntity
+		want     string
+	}{
+		{
+			name: "Nested entities",
+			text: "red car is fast",
+			entities: []tgbotapi.MessageEntity{
+				{Type: "bold", Offset: 0, Length: 7},
+				{Type: "italic", Offset: 4, Length: 3},
+			},
+			want: "<b>red <i>car</i></b> is fast",
+		},
+		{
+			name: "Overlapping entities",
+			text: "red car is fast",
+			entities: []tgbotapi.MessageEntity{
+				{Type: "bold", Offset: 0, Length: 7},
+				{Type: "italic", Offset: 4, Length: 6},
+				{Type: "underline", Offset: 4, Length: 11},
+			},
+			want: "<b>red <u><i>car</i></u></b><u><i> is</i> fast</u>",
+		},
+		{
+			name: "UTF-16 offsets",
+			text: "😀 машина & <code>",
+			entities: []tgbotapi.MessageEntity{
+				{Type: "underline", Offset: 3, Length: 6},
+				{Type: "code", Offset: 12, Length: 6},
+			},
+			want: `😀 <u>машина</u> &amp; <code class="notranslate">&lt;code&gt;</code>`,
+		},
+		{
+			name: "Links",
+			text: "see docs",
+			entities: []tgbotapi.MessageEntity{
+				{Type: "text_link", Offset: 4, Length: 4, URL: "https://example.com/?a=1&b=2"},
+			},
+			want: `see <a href="https://example.com/?a=1&amp;b=2">docs</a>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, entitiesToHTML(tt.text, tt.entities))
+		})
+	}
+}
+
+func TestSanitizeHTML(t *testing.T) {
+	assert.Equal(t,
+		`<b>красная</b> <i>машина</i> <code>x</code> <a href="https://example.com">ссылка</a>`,
+		sanitizeHTML(`<strong>красная</strong> <em>машина</em> <code class="notranslate">x</code> <a href="https://example.com" target="_blank">ссылка</a><span class="notranslate"></span>`))
+	assert.Equal(t, "a < b", htmlToText("<b>a</b> &lt; b"))
+}
@@ -4,17 +4,65 @@ import (
 	"errors"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	gTranslate "github.com/maxik12233/english-helper-telegrambot/pkg/google-translate-sdk"
 	"github.com/maxik12233/english-helper-telegrambot/pkg/logger"
+	offlineTranslate "github.com/maxik12233/english-helper-telegrambot/pkg/offline-translate"
 	"go.uber.org/zap"
 )
 
 var (
-	ErrInternal            = errors.New("Internal error occured, try later.")
-	ErrTranslationApi      = errors.New("Outer API error, try later.")
-	ErrCreatingTranslation = errors.New("Internal gateway error.")
-	ErrSending             = errors.New("Error occured while sending your results.")
+	ErrInternal             = errors.New("Internal error occured, try later.")
+	ErrTranslationApi       = errors.New("Outer API error, try later.")
+	ErrCreatingTranslation  = errors.New("Internal gateway error.")
+	ErrSending              = errors.New("Error occured while sending your results.")
+	ErrTranslationQuota     = errors.New("Translation limit is reached for now, try again in a few minutes.")
+	ErrTranslationLanguage  = errors.New("This language pair is not supported.")
+	ErrTranslationTooLong   = errors.New("Your text is too long to translate, try a shorter one.")
+	ErrNoOfflineTranslation = errors.New("There is no translation for this text in the offline dictionary.")
+	ErrNothingToTranslate   = errors.New("There is no text to translate in this message.")
+	ErrSpeech               = errors.New("Cannot pronounce this text now, try later.")
+	ErrTranscription        = errors.New("Cannot recognize this voice message, try later.")
+	ErrRecognition          = errors.New("Cannot recognize text on this photo, try a sharper one.")
+	ErrVoiceTooLong         = errors.New("Your voice message is too long, keep it under 5 minutes.")
+	ErrNoVocabulary         = errors.New("There are no saved translations in this chat yet, translate some words in Learn mode first.")
+	ErrImportTooLarge       = errors.New("This word list is too large, split it into files under 1 MB and 5000 words.")
+	ErrImportDownload       = errors.New("Cannot download this file, try again later.")
+	ErrImportFormat         = errors.New("Cannot read this file, send a CSV or TSV file, Anki notes exported as plain text or a Quizlet export.")
+	ErrImportEmpty          = errors.New("There are no words in this file.")
 )
 
+// translationError converts an error from the translation service into an error
+// that can be shown to the user.
+func translationError(err error) error {
+	log := logger.GetLogger()
+
+	if errors.Is(err, offlineTranslate.ErrNotFound) {
+		return ErrNoOfflineTranslation
+	}
+
+	var apiErr *gTranslate.APIError
+	if !errors.As(err, &apiErr) {
+		log.Error("Translation request failed", zap.Error(err))
+		return ErrTranslationApi
+	}
+
+	switch apiErr.Kind {
+	case gTranslate.KindQuotaExceeded:
+		log.Warn("Translation quota exceeded", zap.Error(err))
+		return ErrTranslationQuota
+	case gTranslate.KindBadLanguage:
+		return ErrTranslationLanguage
+	case gTranslate.KindTextTooLong:
+		return ErrTranslationTooLong
+	case gTranslate.KindInvalidKey:
+		log.Error("Translation api key is rejected", zap.Error(err))
+		return ErrInternal
+	}
+
+	log.Error("Translation api failed", zap.Error(err), zap.Bool("temporary", apiErr.Temporary()))
+	return ErrTranslationApi
+}
+
 func (b *Bot) handleError(chatid int64, err error) {
 	log := logger.GetLogger()
 	msg := tgbotapi.NewMessage(chatid, "Sorry, something went wrong.")
@@ -28,6 +76,32 @@ func (b *Bot) handleError(chatid int64, err error) {
 		msg.Text = err.Error()
 	case ErrSending:
 		msg.Text = err.Error()
+	case ErrTranslationQuota:
+		msg.Text = err.Error()
+	case ErrTranslationLanguage:
+		msg.Text = err.Error()
+	case ErrTranslationTooLong:
+		msg.Text = err.Error()
+	case ErrNoOfflineTranslation:
+		msg.Text = err.Error()
+	case ErrNothingToTranslate:
+		msg.Text = err.Error()
+	case ErrNoVocabulary:
+		msg.Text = err.Error()
+	case ErrTranscription:
+		msg.Text = err.Error()
+	case ErrVoiceTooLong:
+		msg.Text = err.Error()
+	case ErrRecognition:
+		msg.Text = err.Error()
+	case ErrImportTooLarge:
+		msg.Text = err.Error()
+	case ErrImportDownload:
+		msg.Text = err.Error()
+	case ErrImportFormat:
+		msg.Text = err.Error()
+	case ErrImportEmpty:
+		msg.Text = err.Error()
 	}
 
 	_, err = b.bot.Send(msg)
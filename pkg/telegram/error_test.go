@@ -0,0 +1,30 @@
+package telegram
+
+import (
+	"fmt"
+	"testing"
+
+	gTranslate "github.com/maxik12233/english-helper-telegrambot/pkg/google-translate-sdk"
+	offlineTranslate "github.com/maxik12233/english-helper-telegrambot/pkg/offline-translate"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslationError(t *testing.T) {
+
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{name: "Unknown to the offline dictionary", err: offlineTranslate.ErrNotFound, want: ErrNoOfflineTranslation},
+		{name: "Wrapped", err: fmt.Errorf("translating: %w", offlineTranslate.ErrNotFound), want: ErrNoOfflineTranslation},
+		{name: "Too long", err: &gTranslate.APIError{Kind: gTranslate.KindTextTooLong}, want: ErrTranslationTooLong},
+		{name: "Language", err: &gTranslate.APIError{Kind: gTranslate.KindBadLanguage}, want: ErrTranslationLanguage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, translationError(tt.err))
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package telegram
+
+import (
+	"bytes"
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/maxik12233/english-helper-telegrambot/pkg/db"
+	"github.com/maxik12233/english-helper-telegrambot/pkg/logger"
+	"github.com/maxik12233/english-helper-telegrambot/pkg/vocabulary"
+	"go.uber.org/zap"
+)
+
+const exportDeck = "English Helper"
+
+// vocabularyWords converts translations to words leaving out repeated ones.
+func vocabularyWords(translations []db.Translation) []vocabulary.Word {
+	words := make([]vocabulary.Word, 0, len(translations))
+	for _, t := range translations {
+		words = append(words, vocabulary.Word{
+			Front:  t.SourceText,
+			Back:   t.TargetText,
+			Source: t.Source,
+			Target: t.Target,
+		})
+	}
+	return vocabulary.Unique(words)
+}
+
+// handleExportCommand sends the user's vocabulary as a CSV file and as an
+// Anki package.
+func (b *Bot) handleExportCommand(message *tgbotapi.Message) (*tgbotapi.Message, error) {
+	log := logger.GetLogger()
+
+	translations, err := b.repo.GetTranslations(uint(message.From.ID))
+	if err != nil {
+		return nil, ErrInternal
+	}
+	if len(translations) == 0 {
+		return nil, ErrNoVocabulary
+	}
+	words := vocabularyWords(translations)
+
+	var csvFile, ankiFile bytes.Buffer
+	if err := vocabulary.WriteCSV(&csvFile, words); err != nil {
+		log.Error("Error while writing vocabulary csv", zap.Error(err))
+		return nil, ErrInternal
+	}
+	if err := vocabulary.WriteAnki(&ankiFile, exportDeck, words); err != nil {
+		log.Error("Error while writing anki package", zap.Error(err))
+		return nil, ErrInternal
+	}
+
+	csvDoc := tgbotapi.NewDocument(message.Chat.ID, tgbotapi.FileBytes{Name: "vocabulary.csv", Bytes: csvFile.Bytes()})
+	csvDoc.Caption = fmt.Sprintf("Your vocabulary: %v words.", len(words))
+	if _, err := b.bot.Send(csvDoc); err != nil {
+		return nil, ErrSending
+	}
+
+	ankiDoc := tgbotapi.NewDocument(message.Chat.ID, tgbotapi.FileBytes{Name: "vocabulary.apkg", Bytes: ankiFile.Bytes()})
+	ankiDoc.Caption = "Open it with Anki to import the words as a deck."
+	botmsg, err := b.bot.Send(ankiDoc)
+	if err != nil {
+		return nil, ErrSending
+	}
+
+	return &botmsg, nil
+}
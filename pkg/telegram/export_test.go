@@ -0,0 +1,23 @@
+package telegram
+
+import (
+	"testing"
+
+	"github.com/maxik12233/english-helper-telegrambot/pkg/db"
+	"github.com/maxik12233/english-helper-telegrambot/pkg/vocabulary"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVocabularyWords(t *testing.T) {
+
+	words := vocabularyWords([]db.Translation{
+		{UserID: 1, ChatID: 1, SourceText: "car", TargetText: "машина", Source: "en", Target: "ru"},
+		{UserID: 1, ChatID: -100, SourceText: "car", TargetText: "машина", Source: "en", Target: "ru"},
+		{UserID: 1, ChatID: 1, SourceText: "дом", TargetText: "house", Source: "ru", Target: "en"},
+	})
+
+	assert.Equal(t, []vocabulary.Word{
+		{Front: "car", Back: "машина", Source: "en", Target: "ru"},
+		{Front: "дом", Back: "house", Source: "ru", Target: "en"},
+	}, words)
+}
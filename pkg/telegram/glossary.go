@@ -0,0 +1,158 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/maxik12233/english-helper-telegrambot/pkg/db"
+	"github.com/maxik12233/english-helper-telegrambot/pkg/glossary"
+	"github.com/maxik12233/english-helper-telegrambot/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const glossaryUsage = "Usage:\n/glossary - show your glossary\n/glossary add term = translation\n/glossary remove term"
+
+// translate translates text with the user's language pair keeping the terms
+// from the user's glossary translated the way the user defined them.
+// When isHTML is set, text is an html fragment and only its text is translated.
+func (b *Bot) translate(userid uint, cfg *db.Config, text string, isHTML bool) (string, error) {
+	log := logger.GetLogger()
+
+	entries, err := b.repo.GetGlossary(userid)
+	if err != nil {
+		// Translation still works without the glossary
+		log.Error("Error while getting user glossary", zap.Error(err))
+	}
+
+	g := glossaryFor(entries, cfg.Source, cfg.Target)
+
+	if isHTML {
+		protected, subs := g.ProtectHTML(text)
+		translated, err := b.translateService.TranslateHTML(context.Background(), protected, cfg.Target, cfg.Source)
+		if err != nil {
+			return "", err
+		}
+		return subs.RestoreHTML(translated), nil
+	}
+
+	protected, subs := g.Protect(text)
+	translated, err := b.translateService.TranslateText(protected, cfg.Target, cfg.Source)
+	if err != nil {
+		return "", err
+	}
+
+	return subs.Restore(translated), nil
+}
+
+// glossaryFor selects entries for the language pair. Entries saved for the
+// opposite direction are used reversed.
+func glossaryFor(entries []db.GlossaryEntry, source string, target string) *glossary.Glossary {
+	var selected []glossary.Entry
+	for _, e := range entries {
+		switch {
+		case e.Source == source && e.Target == target:
+			selected = append(selected, glossary.Entry{Term: e.Term, Translation: e.Translation})
+		case e.Source == target && e.Target == source:
+			selected = append(selected, glossary.Entry{Term: e.Translation, Translation: e.Term})
+		}
+	}
+
+	return glossary.New(selected)
+}
+
+func (b *Bot) handleGlossaryCommand(message *tgbotapi.Message) (*tgbotapi.Message, error) {
+
+	cfg, err := b.GetOrCreateUserConfig(uint(message.From.ID))
+	if err != nil {
+		return nil, ErrInternal
+	}
+
+	action, args, _ := strings.Cut(strings.TrimSpace(message.CommandArguments()), " ")
+	var text string
+	switch action {
+	case "":
+		text, err = b.listGlossary(cfg)
+	case "add":
+		text, err = b.addGlossaryEntry(cfg, args)
+	case "remove":
+		text, err = b.removeGlossaryEntry(cfg, args)
+	default:
+		text = glossaryUsage
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	botmsg, err := b.bot.Send(msg)
+	if err != nil {
+		return nil, ErrSending
+	}
+
+	return &botmsg, nil
+}
+
+func (b *Bot) listGlossary(cfg *db.Config) (string, error) {
+
+	entries, err := b.repo.GetGlossary(cfg.UserID)
+	if err != nil {
+		return "", ErrInternal
+	}
+	if len(entries) == 0 {
+		return "Your glossary is empty.\n" + glossaryUsage, nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Your glossary:")
+	for _, e := range entries {
+		sb.WriteString(fmt.Sprintf("\n%v = %v (%v -> %v)", e.Term, e.Translation, e.Source, e.Target))
+	}
+
+	return sb.String(), nil
+}
+
+func (b *Bot) addGlossaryEntry(cfg *db.Config, args string) (string, error) {
+
+	term, translation, ok := strings.Cut(args, "=")
+	term = strings.ToLower(strings.TrimSpace(term))
+	translation = strings.TrimSpace(translation)
+	if !ok || term == "" || translation == "" {
+		return glossaryUsage, nil
+	}
+
+	err := b.repo.SaveGlossaryEntry(&db.GlossaryEntry{
+		UserID:      cfg.UserID,
+		Source:      cfg.Source,
+		Target:      cfg.Target,
+		Term:        term,
+		Translation: translation,
+	})
+	if err != nil {
+		return "", ErrInternal
+	}
+	// Cached translations were made without the new term
+	b.forgetTranslations(cfg.UserID)
+
+	return fmt.Sprintf("Saved: %v = %v (%v -> %v).", term, translation, cfg.Source, cfg.Target), nil
+}
+
+func (b *Bot) removeGlossaryEntry(cfg *db.Config, args string) (string, error) {
+
+	term := strings.ToLower(strings.TrimSpace(args))
+	if term == "" {
+		return glossaryUsage, nil
+	}
+
+	deleted, err := b.repo.DeleteGlossaryEntry(cfg.UserID, term)
+	if err != nil {
+		return "", ErrInternal
+	}
+	if !deleted {
+		return fmt.Sprintf("Term %v is not in your glossary.", term), nil
+	}
+	b.forgetTranslations(cfg.UserID)
+
+	return fmt.Sprintf("Term %v removed.", term), nil
+}
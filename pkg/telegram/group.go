@@ -0,0 +1,316 @@
+package telegram
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/maxik12233/english-helper-telegrambot/pkg/db"
+)
+
+func isGroup(chat *tgbotapi.Chat) bool {
+	return chat != nil && (chat.IsGroup() || chat.IsSuperGroup())
+}
+
+func (b *Bot) isFromMe(message *tgbotapi.Message) bool {
+	return message != nil && message.From != nil && message.From.ID == b.bot.Self.ID
+}
+
+// isAddressedToMe reports whether a group message mentions the bot or
+// replies to one of its messages.
+func (b *Bot) isAddressedToMe(message *tgbotapi.Message) bool {
+	if b.isFromMe(message.ReplyToMessage) {
+		return true
+	}
+
+	c := contentOf(message)
+	for _, e := range c.entities {
+		if b.isMyMention(c.text, e) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (b *Bot) isMyMention(text string, e tgbotapi.MessageEntity) bool {
+	switch e.Type {
+	case "mention":
+		return strings.EqualFold(entityText(text, e), "@"+b.bot.Self.UserName)
+	case "text_mention":
+		return e.User != nil && e.User.ID == b.bot.Self.ID
+	}
+	return false
+}
+
+// isCommandToMe reports whether a command is for this bot. Commands without
+// a bot username are sent to every bot in the chat.
+func (b *Bot) isCommandToMe(message *tgbotapi.Message) bool {
+	_, username, ok := strings.Cut(message.CommandWithAt(), "@")
+	return !ok || strings.EqualFold(username, b.bot.Self.UserName)
+}
+
+func entityText(text string, e tgbotapi.MessageEntity) string {
+	units := utf16.Encode([]rune(text))
+	if e.Offset < 0 || e.Offset+e.Length > len(units) {
+		return ""
+	}
+	return string(utf16.Decode(units[e.Offset : e.Offset+e.Length]))
+}
+
+// withoutMention removes mentions of the bot from the text, moving the
+// remaining entities accordingly.
+func (b *Bot) withoutMention(c content) content {
+	units := utf16.Encode([]rune(c.text))
+
+	removed := make([]bool, len(units))
+	for _, e := range c.entities {
+		if b.isMyMention(c.text, e) && e.Offset+e.Length <= len(units) {
+			for i := e.Offset; i < e.Offset+e.Length; i++ {
+				removed[i] = true
+			}
+		}
+	}
+
+	// Leading spaces left after the mention are dropped too.
+	for i := 0; i < len(units) && (removed[i] || unicode.IsSpace(rune(units[i]))); i++ {
+		removed[i] = true
+	}
+
+	// shift[i] is the number of removed units before position i
+	shift := make([]int, len(units)+1)
+	var kept []uint16
+	for i, u := range units {
+		shift[i+1] = shift[i]
+		if removed[i] {
+			shift[i+1]++
+			continue
+		}
+		kept = append(kept, u)
+	}
+
+	var entities []tgbotapi.MessageEntity
+	for _, e := range c.entities {
+		if e.Offset+e.Length > len(units) || b.isMyMention(c.text, e) {
+			continue
+		}
+		start, end := e.Offset-shift[e.Offset], e.Offset+e.Length-shift[e.Offset+e.Length]
+		if end > start {
+			e.Offset, e.Length = start, end-start
+			entities = append(entities, e)
+		}
+	}
+
+	c.text = strings.TrimRightFunc(string(utf16.Decode(kept)), unicode.IsSpace)
+	c.entities = entities
+	return c
+}
+
+// handleGroupMessage translates only messages addressed to the bot. A mention
+// without text in a reply translates the replied-to message.
+func (b *Bot) handleGroupMessage(message *tgbotapi.Message) error {
+
+	challenge, err := b.activeChallenge(message)
+	if err != nil {
+		return ErrInternal
+	}
+	if challenge != nil {
+		botmsg, err := b.handleChallengeAnswer(message, challenge)
+		if err != nil || botmsg == nil {
+			return err
+		}
+		b.saveMessagesInDb(botmsg, message)
+		return nil
+	}
+
+	if !b.isAddressedToMe(message) {
+		return nil
+	}
+
+	c := b.withoutMention(contentOf(message))
+	if strings.TrimSpace(c.text) == "" && c.voice == nil && message.ReplyToMessage != nil && !b.isFromMe(message.ReplyToMessage) {
+		c = contentOf(message.ReplyToMessage)
+		c.quote = true
+	}
+
+	botmsg, err := b.translateContent(message, c)
+	if err != nil {
+		return err
+	}
+
+	b.saveMessagesInDb(botmsg, message)
+
+	return nil
+}
+
+func (b *Bot) handleGroupCommand(message *tgbotapi.Message) (*tgbotapi.Message, error) {
+
+	switch message.Command() {
+	case commandStart:
+		return b.handleGroupStartCommand(message)
+	case commandChooseMode, commandLanguageSwap:
+		return b.handleGroupSettingsCommand(message)
+	case commandRepeat, commandStopRepeat:
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Repeat sessions are available in a private chat with me.")
+		botmsg, err := b.bot.Send(msg)
+		if err != nil {
+			return nil, ErrSending
+		}
+		return &botmsg, nil
+	case commandPrivacy, commandForgetMe, commandExport, commandImport:
+		msg := tgbotapi.NewMessage(message.Chat.ID, "This command works with your personal data, use it in a private chat with me.")
+		botmsg, err := b.bot.Send(msg)
+		if err != nil {
+			return nil, ErrSending
+		}
+		return &botmsg, nil
+	case commandTranslate:
+		return b.handleTranslateCommand(message)
+	case commandGlossary:
+		return b.handleGlossaryCommand(message)
+	case commandChallenge:
+		return b.handleChallengeCommand(message)
+	case commandTop:
+		return b.handleTopCommand(message)
+	}
+
+	return b.handleUnknownCommand(message)
+}
+
+func (b *Bot) handleGroupStartCommand(message *tgbotapi.Message) (*tgbotapi.Message, error) {
+
+	cfg, err := b.GetOrCreateChatConfig(message.Chat.ID)
+	if err != nil {
+		return nil, ErrInternal
+	}
+
+	text := fmt.Sprintf("Hi! Mention me with a text (@%v text) or reply to a message with @%v to translate it. "+
+		"Chat settings: %v -> %v, mode - %v. Admins can change them with /swap and /mode. "+
+		"Words translated in Learn mode make up the chat's vocabulary: practice it with /challenge and see the best players with /top.",
+		b.bot.Self.UserName, b.bot.Self.UserName, cfg.Source, cfg.Target, cfg.Mode)
+	if b.bot.Self.CanReadAllGroupMessages {
+		text += "\nI can read all messages here, but I answer only the ones addressed to me."
+	} else {
+		text += "\nPrivacy mode is on, so I see only commands, mentions and replies to my messages."
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	botmsg, err := b.bot.Send(msg)
+	if err != nil {
+		return nil, ErrSending
+	}
+
+	return &botmsg, nil
+}
+
+// handleGroupSettingsCommand changes chat settings, which only chat
+// administrators are allowed to do.
+func (b *Bot) handleGroupSettingsCommand(message *tgbotapi.Message) (*tgbotapi.Message, error) {
+
+	isAdmin, err := b.isChatAdmin(message)
+	if err != nil {
+		return nil, ErrInternal
+	}
+
+	cfg, err := b.GetOrCreateChatConfig(message.Chat.ID)
+	if err != nil {
+		return nil, ErrInternal
+	}
+
+	var text string
+	switch {
+	case !isAdmin:
+		text = "Only chat administrators can change chat settings."
+	case message.Command() == commandChooseMode:
+		if cfg.Mode == modeLearn {
+			cfg.Mode = modeTranslate
+		} else {
+			cfg.Mode = modeLearn
+		}
+		text = fmt.Sprintf("Chat mode saved to - %v.", cfg.Mode)
+	default:
+		cfg.Source, cfg.Target = cfg.Target, cfg.Source
+		text = fmt.Sprintf("Chat languages saved. Current settings: %v -> %v.", cfg.Source, cfg.Target)
+	}
+
+	if isAdmin {
+		if err := b.repo.UpdateChatConfig(cfg); err != nil {
+			return nil, ErrInternal
+		}
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	botmsg, err := b.bot.Send(msg)
+	if err != nil {
+		return nil, ErrSending
+	}
+
+	return &botmsg, nil
+}
+
+func (b *Bot) isChatAdmin(message *tgbotapi.Message) (bool, error) {
+	// Anonymous administrators send messages on behalf of the chat
+	if message.SenderChat != nil && message.SenderChat.ID == message.Chat.ID {
+		return true, nil
+	}
+
+	member, err := b.bot.GetChatMember(tgbotapi.GetChatMemberConfig{
+		ChatConfigWithUser: tgbotapi.ChatConfigWithUser{
+			ChatID: message.Chat.ID,
+			UserID: message.From.ID,
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return member.IsCreator() || member.IsAdministrator(), nil
+}
+
+func (b *Bot) GetOrCreateChatConfig(chatid int64) (*db.ChatConfig, error) {
+
+	cfg, err := b.repo.GetChatConfig(chatid)
+	if err == db.ErrNotFound {
+		cfg = &db.ChatConfig{
+			ChatID: chatid,
+			Source: sourceDefault,
+			Target: targetDefault,
+			Mode:   modeDefault,
+		}
+		err := b.repo.CreateChatConfig(cfg)
+		if err == db.ErrConflict {
+			return b.repo.GetChatConfig(chatid)
+		}
+		if err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// configFor returns settings to translate the message with: user's own
+// settings in a private chat and chat's language pair and mode in a group.
+func (b *Bot) configFor(message *tgbotapi.Message) (*db.Config, error) {
+
+	cfg, err := b.GetOrCreateUserConfig(uint(message.From.ID))
+	if err != nil || !isGroup(message.Chat) {
+		return cfg, err
+	}
+
+	chatCfg, err := b.GetOrCreateChatConfig(message.Chat.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	groupCfg := *cfg
+	groupCfg.Source = chatCfg.Source
+	groupCfg.Target = chatCfg.Target
+	groupCfg.Mode = chatCfg.Mode
+
+	return &groupCfg, nil
+}
@@ -0,0 +1,65 @@
+package telegram
+
+import (
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithoutMention(t *testing.T) {
+
+	b := &Bot{bot: &tgbotapi.BotAPI{Self: tgbotapi.User{ID: 1, UserName: "helperbot"}}}
+
+	tests := []struct {
+		name         string
+		text         string
+		entities     []tgbotapi.MessageEntity
+		wantText     string
+		wantEntities []tgbotapi.MessageEntity
+	}{
+		{
+			name: "Leading mention",
+			text: "@helperbot red car",
+			entities: []tgbotapi.MessageEntity{
+				{Type: "mention", Offset: 0, Length: 10},
+				{Type: "bold", Offset: 11, Length: 3},
+			},
+			wantText:     "red car",
+			wantEntities: []tgbotapi.MessageEntity{{Type: "bold", Offset: 0, Length: 3}},
+		},
+		{
+			name: "Mention in the middle",
+			text: "😀 red @HelperBot car",
+			entities: []tgbotapi.MessageEntity{
+				{Type: "mention", Offset: 7, Length: 10},
+				{Type: "italic", Offset: 18, Length: 3},
+			},
+			wantText:     "😀 red  car",
+			wantEntities: []tgbotapi.MessageEntity{{Type: "italic", Offset: 8, Length: 3}},
+		},
+		{
+			name: "Other mentions are kept",
+			text: "@otherbot car",
+			entities: []tgbotapi.MessageEntity{
+				{Type: "mention", Offset: 0, Length: 9},
+			},
+			wantText:     "@otherbot car",
+			wantEntities: []tgbotapi.MessageEntity{{Type: "mention", Offset: 0, Length: 9}},
+		},
+		{
+			name:     "Only mention",
+			text:     "@helperbot ",
+			entities: []tgbotapi.MessageEntity{{Type: "mention", Offset: 0, Length: 10}},
+			wantText: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := b.withoutMention(content{text: tt.text, entities: tt.entities})
+			assert.Equal(t, tt.wantText, got.text)
+			assert.Equal(t, tt.wantEntities, got.entities)
+		})
+	}
+}
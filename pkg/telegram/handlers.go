@@ -2,6 +2,8 @@ package telegram
 
 import (
 	"fmt"
+	"html"
+	"strings"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/maxik12233/english-helper-telegrambot/pkg/db"
@@ -15,6 +17,14 @@ const (
 	commandLanguageSwap = "swap"
 	commandRepeat       = "repeat"
 	commandStopRepeat   = "stop"
+	commandGlossary     = "glossary"
+	commandTranslate    = "translate"
+	commandChallenge    = "challenge"
+	commandTop          = "top"
+	commandPrivacy      = "privacy"
+	commandForgetMe     = "forgetme"
+	commandExport       = "export"
+	commandImport       = "import"
 
 	modeLearn     = "Learn"
 	modeTranslate = "Translate"
@@ -27,22 +37,34 @@ const (
 	modeDefault   = modeLearn
 )
 
-var defaultCfg = db.Config{
-	UserID: 0, // Rewrite when used to create db instance
-	Target: targetDefault,
-	Source: sourceDefault,
-	Mode:   modeDefault,
+// defaultConfig returns a new config for a user who has none yet.
+func defaultConfig(userid uint) *db.Config {
+	return &db.Config{
+		UserID: userid,
+		Target: targetDefault,
+		Source: sourceDefault,
+		Mode:   modeDefault,
+	}
 }
 
 func (b *Bot) saveMessagesInDb(botmsg *tgbotapi.Message, message *tgbotapi.Message) error {
 	log := logger.GetLogger()
 
+	enabled, err := b.historyEnabled(uint(message.From.ID))
+	if err != nil {
+		log.Error("Error getting history setting", zap.Error(err))
+		return err
+	}
+	if !enabled {
+		return nil
+	}
+
 	log.Info("Saving messages in database.", zap.Any("botmsg", botmsg), zap.Any("usermsg", message))
 	// Save bot's and user's message in db
 	if err := b.repo.CreateMessage(&db.Message{
 		UserID:     uint(message.From.ID),
-		ChatID:     uint(message.Chat.ID),
-		Text:       message.Text,
+		ChatID:     message.Chat.ID,
+		Text:       contentOf(message).text,
 		BotMessage: false,
 	}); err != nil {
 		log.Error("Error saving message in database", zap.Error(err))
@@ -50,7 +72,7 @@ func (b *Bot) saveMessagesInDb(botmsg *tgbotapi.Message, message *tgbotapi.Messa
 	}
 	if err := b.repo.CreateMessage(&db.Message{
 		UserID:     uint(message.From.ID),
-		ChatID:     uint(message.Chat.ID),
+		ChatID:     message.Chat.ID,
 		Text:       botmsg.Text,
 		BotMessage: true,
 	}); err != nil {
@@ -64,6 +86,19 @@ func (b *Bot) saveMessagesInDb(botmsg *tgbotapi.Message, message *tgbotapi.Messa
 func (b *Bot) handleMessage(message *tgbotapi.Message) error {
 	log := logger.GetLogger()
 
+	if isGroup(message.Chat) {
+		return b.handleGroupMessage(message)
+	}
+
+	if isWordList(message.Document) {
+		botmsg, err := b.handleDocument(message)
+		if err != nil {
+			return err
+		}
+		b.saveMessagesInDb(botmsg, message)
+		return nil
+	}
+
 	cfg, err := b.GetOrCreateUserConfig(uint(message.From.ID))
 	if err != nil {
 		return ErrInternal
@@ -123,32 +158,96 @@ func (b *Bot) handleRepeatMessage(message *tgbotapi.Message) (*tgbotapi.Message,
 }
 
 func (b *Bot) handleTranslateMessage(message *tgbotapi.Message) (*tgbotapi.Message, error) {
+	return b.translateContent(message, contentOf(message))
+}
+
+// translateContent translates c for the author of message and replies with
+// the translation.
+func (b *Bot) translateContent(message *tgbotapi.Message, c content) (*tgbotapi.Message, error) {
 	log := logger.GetLogger()
 
+	if strings.TrimSpace(c.text) == "" && !b.hasMedia(c) {
+		return nil, ErrNothingToTranslate
+	}
+
 	msg := tgbotapi.NewMessage(message.Chat.ID, "Sorry, something went wrong.")
+	msg.ReplyToMessageID = c.messageID
 
-	cfg, err := b.GetOrCreateUserConfig(uint(message.From.ID))
+	cfg, err := b.configFor(message)
 	if err != nil {
 		return nil, ErrInternal
 	}
 	log.Info("Obtained config", zap.Any("Config", cfg))
 
-	resultText, err := b.translateService.TranslateText(message.Text, cfg.Target, cfg.Source)
+	switch {
+	case c.voice != nil && b.transcriber != nil:
+		c, err = b.transcribe(c, cfg.Source)
+	case c.photo != nil && b.recognizer != nil:
+		c, err = b.recognize(c, cfg.Source)
+	}
 	if err != nil {
-		return nil, ErrTranslationApi
+		return nil, err
+	}
+
+	// Formatted messages are translated as html to keep their formatting
+	formatted := hasFormatting(c.entities)
+	text := c.text
+	if formatted {
+		text = entitiesToHTML(c.text, c.entities)
+	}
+
+	resultText, err := b.translate(uint(message.From.ID), cfg, text, formatted)
+	if err != nil {
+		return nil, translationError(err)
 	}
 
 	if resultText != "" {
 		msg.Text = resultText
+		isHTML := formatted || c.quote
+		if isHTML {
+			translated := html.EscapeString(resultText)
+			if formatted {
+				translated = sanitizeHTML(resultText)
+				resultText = htmlToText(translated)
+			}
+			msg.ParseMode = tgbotapi.ModeHTML
+			msg.Text = translated
+		}
+		if details := b.lookupWord(c.text, cfg); details != "" {
+			visible := msg.Text
+			if isHTML {
+				visible = htmlToText(msg.Text)
+			}
+			// Details get what is left of the message length limit
+			details = truncateText(details, maxMessageLength-textLength(visible+"\n\n"))
+			if isHTML {
+				details = html.EscapeString(details)
+			}
+			if details != "" {
+				msg.Text += "\n\n" + details
+			}
+		}
+		if isHTML {
+			// The quote gets what is left of the message length limit
+			msg.Text = c.quoteHTML(maxMessageLength-textLength(htmlToText(msg.Text))) + msg.Text
+		}
+		keyboard := b.pronounceKeyboard(c.text, cfg.Source)
+		if c.photo != nil {
+			keyboard = saveWordsKeyboard(c.text, cfg.Source, cfg.Target)
+		}
+		if keyboard != nil {
+			msg.ReplyMarkup = keyboard
+		}
 
-		if cfg.Mode == modeLearn {
+		// Text of photos is saved word by word with the buttons instead
+		if cfg.Mode == modeLearn && c.photo == nil {
 			// Save result of translation operation in db if mode learn
 			// If translation was performed (dont depends on send error)
 			if err := b.repo.CreateTranslation(&db.Translation{
 				UserID:     uint(message.From.ID),
-				ChatID:     uint(message.Chat.ID),
-				SourceText: message.Text,
-				TargetText: msg.Text,
+				ChatID:     message.Chat.ID,
+				SourceText: c.text,
+				TargetText: resultText,
 				Source:     cfg.Source,
 				Target:     cfg.Target,
 			}); err != nil {
@@ -169,6 +268,18 @@ func (b *Bot) handleCommand(message *tgbotapi.Message) error {
 
 	var botmsg *tgbotapi.Message
 	var err error
+	if isGroup(message.Chat) {
+		if !b.isCommandToMe(message) {
+			return nil
+		}
+		botmsg, err = b.handleGroupCommand(message)
+		if err != nil {
+			return err
+		}
+		b.saveMessagesInDb(botmsg, message)
+		return nil
+	}
+
 	switch message.Command() {
 	case commandStart:
 		botmsg, err = b.handleStartCommand(message)
@@ -195,6 +306,41 @@ func (b *Bot) handleCommand(message *tgbotapi.Message) error {
 		if err != nil {
 			return err
 		}
+	case commandGlossary:
+		botmsg, err = b.handleGlossaryCommand(message)
+		if err != nil {
+			return err
+		}
+	case commandTranslate:
+		botmsg, err = b.handleTranslateCommand(message)
+		if err != nil {
+			return err
+		}
+	case commandImport:
+		botmsg, err = b.handleImportCommand(message)
+		if err != nil {
+			return err
+		}
+	case commandExport:
+		botmsg, err = b.handleExportCommand(message)
+		if err != nil {
+			return err
+		}
+	case commandPrivacy:
+		botmsg, err = b.handlePrivacyCommand(message)
+		if err != nil {
+			return err
+		}
+	case commandForgetMe:
+		botmsg, err = b.handleForgetMeCommand(message)
+		if err != nil {
+			return err
+		}
+	case commandChallenge, commandTop:
+		botmsg, err = b.handleGroupOnlyCommand(message)
+		if err != nil {
+			return err
+		}
 	default:
 		botmsg, err = b.handleUnknownCommand(message)
 		if err != nil {
@@ -207,21 +353,23 @@ func (b *Bot) handleCommand(message *tgbotapi.Message) error {
 	return nil
 }
 
+// repeatWord sends a random word of the user's vocabulary and waits for its
+// translation in repeat mode.
 func (b *Bot) repeatWord(message *tgbotapi.Message) (*tgbotapi.Message, error) {
-	cfg, err := b.GetOrCreateUserConfig(uint(message.From.ID))
-	if err != nil {
-		return nil, ErrInternal
-	}
 
-	trnsl, err := b.repo.GetRandomTranslation()
-	if err != nil {
+	trnsl, err := b.repo.GetRandomTranslation(uint(message.From.ID))
+	if err == db.ErrNotFound {
+		return nil, ErrNoVocabulary
+	} else if err != nil {
 		return nil, err
 	}
 
-	cfg.TranslationWord = trnsl.TargetText
-	err = b.repo.UpdateConfig(cfg)
+	_, err = b.updateUserConfig(uint(message.From.ID), func(cfg *db.Config) {
+		cfg.Mode = modeRepeat
+		cfg.TranslationWord = trnsl.TargetText
+	})
 	if err != nil {
-		return nil, err
+		return nil, ErrInternal
 	}
 
 	msg := tgbotapi.NewMessage(message.Chat.ID, trnsl.SourceText)
@@ -235,12 +383,9 @@ func (b *Bot) repeatWord(message *tgbotapi.Message) (*tgbotapi.Message, error) {
 
 func (b *Bot) handleStopRepeatCommand(message *tgbotapi.Message) (*tgbotapi.Message, error) {
 
-	cfg, err := b.GetOrCreateUserConfig(uint(message.From.ID))
-	if err != nil {
-		return nil, ErrInternal
-	}
-	cfg.Mode = modeDefault
-	b.repo.UpdateConfig(cfg)
+	_, err := b.updateUserConfig(uint(message.From.ID), func(cfg *db.Config) {
+		cfg.Mode = modeDefault
+	})
 	if err != nil {
 		return nil, ErrInternal
 	}
@@ -251,23 +396,7 @@ func (b *Bot) handleStopRepeatCommand(message *tgbotapi.Message) (*tgbotapi.Mess
 }
 
 func (b *Bot) handleRepeatCommand(message *tgbotapi.Message) (*tgbotapi.Message, error) {
-
-	cfg, err := b.GetOrCreateUserConfig(uint(message.From.ID))
-	if err != nil {
-		return nil, ErrInternal
-	}
-	cfg.Mode = modeRepeat
-	b.repo.UpdateConfig(cfg)
-	if err != nil {
-		return nil, err
-	}
-
-	botmsg, err := b.repeatWord(message)
-	if err != nil {
-		return nil, err
-	}
-
-	return botmsg, nil
+	return b.repeatWord(message)
 }
 
 func (b *Bot) handleStartCommand(message *tgbotapi.Message) (*tgbotapi.Message, error) {
@@ -289,17 +418,13 @@ func (b *Bot) handleStartCommand(message *tgbotapi.Message) (*tgbotapi.Message,
 
 func (b *Bot) handleChooseModeCommand(message *tgbotapi.Message) (*tgbotapi.Message, error) {
 
-	cfg, err := b.GetOrCreateUserConfig(uint(message.From.ID))
-	if err != nil {
-		return nil, ErrInternal
-	}
-
-	if cfg.Mode == modeLearn {
-		cfg.Mode = modeTranslate
-	} else {
-		cfg.Mode = modeLearn
-	}
-	err = b.repo.UpdateConfig(cfg)
+	cfg, err := b.updateUserConfig(uint(message.From.ID), func(cfg *db.Config) {
+		if cfg.Mode == modeLearn {
+			cfg.Mode = modeTranslate
+		} else {
+			cfg.Mode = modeLearn
+		}
+	})
 	if err != nil {
 		return nil, ErrInternal
 	}
@@ -315,21 +440,27 @@ func (b *Bot) handleChooseModeCommand(message *tgbotapi.Message) (*tgbotapi.Mess
 
 func (b *Bot) handleSwapCommand(message *tgbotapi.Message) (*tgbotapi.Message, error) {
 
-	cfg, err := b.GetOrCreateUserConfig(uint(message.From.ID))
+	cfg, err := b.updateUserConfig(uint(message.From.ID), func(cfg *db.Config) {
+		templ := cfg.Target
+		cfg.Target = cfg.Source
+		cfg.Source = templ
+	})
 	if err != nil {
 		return nil, ErrInternal
 	}
 
-	templ := cfg.Target
-	cfg.Target = cfg.Source
-	cfg.Source = templ
-
-	err = b.repo.UpdateConfig(cfg)
+	msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("Languages saved. Current settings: %v -> %v.", cfg.Source, cfg.Target))
+	botmsg, err := b.bot.Send(msg)
 	if err != nil {
-		return nil, ErrInternal
+		return nil, ErrSending
 	}
 
-	msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("Languages saved. Current settings: %v -> %v.", cfg.Source, cfg.Target))
+	return &botmsg, nil
+}
+
+func (b *Bot) handleGroupOnlyCommand(message *tgbotapi.Message) (*tgbotapi.Message, error) {
+	msg := tgbotapi.NewMessage(message.Chat.ID, "Challenges are available in group chats, add me to a group to play with friends.")
+
 	botmsg, err := b.bot.Send(msg)
 	if err != nil {
 		return nil, ErrSending
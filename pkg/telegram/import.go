@@ -0,0 +1,247 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/maxik12233/english-helper-telegrambot/pkg/db"
+	"github.com/maxik12233/english-helper-telegrambot/pkg/logger"
+	"github.com/maxik12233/english-helper-telegrambot/pkg/vocabulary"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxImportSize and maxImportWords keep parsing and translating of a word
+	// list reasonably fast
+	maxImportSize  = 1 << 20
+	maxImportWords = 5000
+	importTimeout  = 2 * time.Minute
+
+	// importNoTranslate as the caption of a document turns off translation of
+	// words which have none
+	importNoTranslate = "notranslate"
+)
+
+const importUsage = "Send me a word list as a document: a CSV or TSV file with words and translations, " +
+	"notes exported from Anki as plain text or a Quizlet export. " +
+	"Words without a translation are translated with your language settings, " +
+	"add the caption " + importNoTranslate + " to skip them instead."
+
+// importSummary counts what happened to the rows of a word list.
+type importSummary struct {
+	imported   int
+	skipped    int
+	duplicates int
+}
+
+// wordSet finds words already in the vocabulary. Words without a translation
+// are found by the text alone.
+type wordSet struct {
+	words  map[string]bool
+	fronts map[string]bool
+}
+
+func newWordSet() wordSet {
+	return wordSet{words: make(map[string]bool), fronts: make(map[string]bool)}
+}
+
+func frontKey(w vocabulary.Word) string {
+	return vocabulary.Word{Front: w.Front, Source: w.Source, Target: w.Target}.Key()
+}
+
+func (s wordSet) has(w vocabulary.Word) bool {
+	if w.Back == "" {
+		return s.fronts[frontKey(w)]
+	}
+	return s.words[w.Key()]
+}
+
+func (s wordSet) add(w vocabulary.Word) {
+	s.fronts[frontKey(w)] = true
+	if w.Back != "" {
+		s.words[w.Key()] = true
+	}
+}
+
+// wordListExtensions are the extensions of documents imported as word lists.
+var wordListExtensions = map[string]bool{".csv": true, ".tsv": true, ".txt": true}
+
+// isWordList reports whether the document is text to import as a word list.
+// Captions of other documents are translated like any message.
+func isWordList(doc *tgbotapi.Document) bool {
+	if doc == nil {
+		return false
+	}
+	return strings.HasPrefix(doc.MimeType, "text/") || wordListExtensions[strings.ToLower(filepath.Ext(doc.FileName))]
+}
+
+func (b *Bot) handleImportCommand(message *tgbotapi.Message) (*tgbotapi.Message, error) {
+	msg := tgbotapi.NewMessage(message.Chat.ID, importUsage)
+
+	botmsg, err := b.bot.Send(msg)
+	if err != nil {
+		return nil, ErrSending
+	}
+
+	return &botmsg, nil
+}
+
+// handleDocument imports the word list sent as a document into the user's
+// vocabulary.
+func (b *Bot) handleDocument(message *tgbotapi.Message) (*tgbotapi.Message, error) {
+	log := logger.GetLogger()
+
+	if message.Document.FileSize > maxImportSize {
+		return nil, ErrImportTooLarge
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), importTimeout)
+	defer cancel()
+
+	data, err := b.downloadFile(ctx, message.Document.FileID)
+	if err != nil {
+		log.Error("Error while downloading a document", zap.Error(err))
+		return nil, ErrImportDownload
+	}
+
+	parsed, err := vocabulary.Parse(data)
+	switch {
+	case err == vocabulary.ErrNoWords:
+		return nil, ErrImportEmpty
+	case err != nil:
+		return nil, ErrImportFormat
+	case len(parsed.Words) > maxImportWords:
+		return nil, ErrImportTooLarge
+	}
+
+	cfg, err := b.GetOrCreateUserConfig(uint(message.From.ID))
+	if err != nil {
+		return nil, ErrInternal
+	}
+	known, err := b.repo.GetTranslations(uint(message.From.ID))
+	if err != nil {
+		return nil, ErrInternal
+	}
+
+	translate := !strings.EqualFold(strings.TrimSpace(message.Caption), importNoTranslate)
+	words, summary, err := b.importWords(ctx, cfg, parsed.Words, known, translate)
+	if err != nil {
+		// The rest of the words are still imported
+		log.Error("Error while translating imported words", zap.Error(err))
+	}
+	summary.skipped += parsed.Skipped
+
+	translations := make([]db.Translation, 0, len(words))
+	for _, w := range words {
+		translations = append(translations, db.Translation{
+			UserID:     uint(message.From.ID),
+			ChatID:     message.Chat.ID,
+			SourceText: w.Front,
+			TargetText: w.Back,
+			Source:     w.Source,
+			Target:     w.Target,
+		})
+	}
+	if err := b.repo.CreateTranslations(translations); err != nil {
+		return nil, ErrCreatingTranslation
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("Imported: %v, skipped: %v, duplicates: %v.",
+		summary.imported, summary.skipped, summary.duplicates))
+	msg.ReplyToMessageID = message.MessageID
+	botmsg, err := b.bot.Send(msg)
+	if err != nil {
+		return nil, ErrSending
+	}
+
+	return &botmsg, nil
+}
+
+// importWords selects words which are not in the known translations yet,
+// taking the user's languages for words without them. Words without a
+// translation are translated if translate is set and skipped otherwise.
+// err tells why translation failed, the words are skipped then.
+func (b *Bot) importWords(ctx context.Context, cfg *db.Config, words []vocabulary.Word, known []db.Translation, translate bool) (ready []vocabulary.Word, summary importSummary, err error) {
+
+	seen := newWordSet()
+	for _, t := range known {
+		seen.add(vocabulary.Word{Front: t.SourceText, Back: t.TargetText, Source: t.Source, Target: t.Target})
+	}
+
+	var untranslated []vocabulary.Word
+	for _, w := range words {
+		if w.Source == "" || w.Target == "" {
+			w.Source, w.Target = cfg.Source, cfg.Target
+		}
+		if seen.has(w) {
+			summary.duplicates++
+			continue
+		}
+		seen.add(w)
+
+		if w.Back == "" {
+			untranslated = append(untranslated, w)
+		} else {
+			ready = append(ready, w)
+		}
+	}
+
+	if translate {
+		var translated []vocabulary.Word
+		var failed int
+		translated, failed, err = b.translateWords(ctx, untranslated)
+		ready = append(ready, translated...)
+		summary.skipped += failed
+	} else {
+		summary.skipped += len(untranslated)
+	}
+
+	summary.imported = len(ready)
+	return ready, summary, err
+}
+
+// translateWords translates the words in one batch per language pair. Words
+// of failed batches are counted and the last error is returned.
+func (b *Bot) translateWords(ctx context.Context, words []vocabulary.Word) (translated []vocabulary.Word, failed int, err error) {
+
+	type pair struct{ source, target string }
+	var pairs []pair
+	batches := make(map[pair][]vocabulary.Word)
+	for _, w := range words {
+		p := pair{w.Source, w.Target}
+		if _, ok := batches[p]; !ok {
+			pairs = append(pairs, p)
+		}
+		batches[p] = append(batches[p], w)
+	}
+
+	for _, p := range pairs {
+		batch := batches[p]
+		texts := make([]string, len(batch))
+		for i, w := range batch {
+			texts[i] = w.Front
+		}
+
+		res, batchErr := b.translateService.TranslateBatch(ctx, texts, p.target, p.source)
+		if batchErr != nil {
+			err = batchErr
+			failed += len(batch)
+			continue
+		}
+		for i, w := range batch {
+			w.Back = strings.TrimSpace(res[i])
+			// Translators may keep the text they have no translation for
+			if w.Back == "" || strings.EqualFold(w.Back, w.Front) {
+				failed++
+				continue
+			}
+			translated = append(translated, w)
+		}
+	}
+
+	return translated, failed, err
+}
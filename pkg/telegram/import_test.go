@@ -0,0 +1,163 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/maxik12233/english-helper-telegrambot/pkg/db"
+	"github.com/maxik12233/english-helper-telegrambot/pkg/vocabulary"
+	"github.com/stretchr/testify/assert"
+)
+
+// batchTranslator translates texts from its dictionary, keeping unknown ones
+// as they are like the offline translator, and fails for the language pairs
+// in failing.
+type batchTranslator struct {
+	dictionary map[string]string
+	failing    map[string]bool
+	batches    int
+}
+
+func (t *batchTranslator) TranslateText(text string, target string, source string) (string, error) {
+	return t.dictionary[text], nil
+}
+
+func (t *batchTranslator) TranslateBatch(ctx context.Context, texts []string, target string, source string) ([]string, error) {
+	t.batches++
+	if t.failing[source+"-"+target] {
+		return nil, errors.New("translation failed")
+	}
+	translated := make([]string, len(texts))
+	for i, text := range texts {
+		translated[i] = text
+		if tr, ok := t.dictionary[text]; ok {
+			translated[i] = tr
+		}
+	}
+	return translated, nil
+}
+
+func (t *batchTranslator) TranslateHTML(ctx context.Context, html string, target string, source string) (string, error) {
+	return html, nil
+}
+
+func TestImportWords(t *testing.T) {
+
+	cfg := &db.Config{UserID: 1, Source: "en", Target: "ru"}
+	known := []db.Translation{
+		{UserID: 1, SourceText: "car", TargetText: "машина", Source: "en", Target: "ru"},
+	}
+	words := []vocabulary.Word{
+		{Front: "Car", Back: "Машина"},
+		{Front: "car"},
+		{Front: "house", Back: "дом"},
+		{Front: "house", Back: "дом"},
+		{Front: "cat"},
+		{Front: "Cat"},
+		{Front: "unknown"},
+		{Front: "Haus", Source: "de", Target: "en"},
+		{Front: "dog", Back: "собака", Source: "en", Target: "ru"},
+	}
+
+	tests := []struct {
+		name        string
+		translate   bool
+		failing     map[string]bool
+		wantWords   []vocabulary.Word
+		wantSummary importSummary
+		wantErr     bool
+		wantBatches int
+	}{
+		{
+			name:      "Translated",
+			translate: true,
+			wantWords: []vocabulary.Word{
+				{Front: "house", Back: "дом", Source: "en", Target: "ru"},
+				{Front: "dog", Back: "собака", Source: "en", Target: "ru"},
+				{Front: "cat", Back: "кот", Source: "en", Target: "ru"},
+				{Front: "Haus", Back: "house", Source: "de", Target: "en"},
+			},
+			// There is no translation for unknown
+			wantSummary: importSummary{imported: 4, skipped: 1, duplicates: 4},
+			wantBatches: 2,
+		},
+		{
+			name:      "Not translated",
+			translate: false,
+			wantWords: []vocabulary.Word{
+				{Front: "house", Back: "дом", Source: "en", Target: "ru"},
+				{Front: "dog", Back: "собака", Source: "en", Target: "ru"},
+			},
+			wantSummary: importSummary{imported: 2, skipped: 3, duplicates: 4},
+		},
+		{
+			name:      "Translation failed",
+			translate: true,
+			failing:   map[string]bool{"en-ru": true},
+			wantWords: []vocabulary.Word{
+				{Front: "house", Back: "дом", Source: "en", Target: "ru"},
+				{Front: "dog", Back: "собака", Source: "en", Target: "ru"},
+				{Front: "Haus", Back: "house", Source: "de", Target: "en"},
+			},
+			wantSummary: importSummary{imported: 3, skipped: 2, duplicates: 4},
+			wantErr:     true,
+			wantBatches: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			translator := &batchTranslator{
+				dictionary: map[string]string{"cat": "кот", "Haus": "house"},
+				failing:    tt.failing,
+			}
+			b := &Bot{translateService: translator}
+
+			got, summary, err := b.importWords(context.Background(), cfg, words, known, tt.translate)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.wantWords, got)
+			assert.Equal(t, tt.wantSummary, summary)
+			assert.Equal(t, tt.wantBatches, translator.batches)
+		})
+	}
+}
+
+func TestIsWordList(t *testing.T) {
+
+	tests := []struct {
+		name string
+		doc  *tgbotapi.Document
+		want bool
+	}{
+		{name: "No document", doc: nil, want: false},
+		{name: "CSV", doc: &tgbotapi.Document{FileName: "words.CSV", MimeType: "application/octet-stream"}, want: true},
+		{name: "TSV", doc: &tgbotapi.Document{FileName: "words.tsv"}, want: true},
+		{name: "Anki notes", doc: &tgbotapi.Document{FileName: "notes.txt", MimeType: "text/plain"}, want: true},
+		{name: "Text without extension", doc: &tgbotapi.Document{FileName: "words", MimeType: "text/csv"}, want: true},
+		{name: "PDF", doc: &tgbotapi.Document{FileName: "article.pdf", MimeType: "application/pdf"}, want: false},
+		{name: "Image", doc: &tgbotapi.Document{FileName: "photo.png", MimeType: "image/png"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isWordList(tt.doc))
+		})
+	}
+}
+
+func TestCaptionedDocument(t *testing.T) {
+
+	message := &tgbotapi.Message{
+		MessageID: 2,
+		Document:  &tgbotapi.Document{FileName: "article.pdf", MimeType: "application/pdf"},
+		Caption:   "Read this article",
+	}
+
+	// The document is not imported, its caption is translated instead
+	assert.False(t, isWordList(message.Document))
+	c := contentOf(message)
+	assert.Equal(t, "Read this article", c.text)
+	assert.True(t, c.quote)
+}
@@ -0,0 +1,186 @@
+package telegram
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/maxik12233/english-helper-telegrambot/pkg/db"
+	"github.com/maxik12233/english-helper-telegrambot/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	// inlineDebounce is how long to wait for the user to stop typing
+	inlineDebounce  = 400 * time.Millisecond
+	inlineCacheTime = 300
+	inlineCacheSize = 1000
+	inlineCacheTTL  = 30 * time.Minute
+
+	inlineResultTranslate = "translate"
+	inlineResultSwap      = "swap"
+	// inlineResultSave starts the ids of results saved to the user's vocabulary
+	// when chosen. Telegram reports chosen results only if inline feedback is
+	// enabled in BotFather.
+	inlineResultSave = "save"
+)
+
+// inlineState tracks the latest inline query of every user, so that queries
+// typed in quick succession are answered only once.
+type inlineState struct {
+	mu     sync.Mutex
+	latest map[int64]string
+	cache  *translationCache
+	// offers keeps the translations shown in save results by their ids, so
+	// that the one the user saw is saved
+	offers *translationCache
+}
+
+func newInlineState() *inlineState {
+	return &inlineState{
+		latest: make(map[int64]string),
+		cache:  newTranslationCache(inlineCacheSize, inlineCacheTTL),
+		offers: newTranslationCache(inlineCacheSize, inlineCacheTTL),
+	}
+}
+
+func (s *inlineState) setLatest(userid int64, queryID string) {
+	s.mu.Lock()
+	s.latest[userid] = queryID
+	s.mu.Unlock()
+}
+
+// isLatest reports whether queryID is still the last query of the user and
+// forgets it if so.
+func (s *inlineState) isLatest(userid int64, queryID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.latest[userid] != queryID {
+		return false
+	}
+	delete(s.latest, userid)
+	return true
+}
+
+func (b *Bot) handleInlineQuery(query *tgbotapi.InlineQuery) {
+	b.inline.setLatest(query.From.ID, query.ID)
+
+	go func() {
+		log := logger.GetLogger()
+
+		time.Sleep(inlineDebounce)
+		if !b.inline.isLatest(query.From.ID, query.ID) {
+			// The user kept typing, a newer query will be answered
+			return
+		}
+
+		if err := b.answerInlineQuery(query); err != nil {
+			log.Error("Error while answering inline query", zap.Error(err))
+		}
+	}()
+}
+
+func (b *Bot) answerInlineQuery(query *tgbotapi.InlineQuery) error {
+
+	answer := tgbotapi.InlineConfig{
+		InlineQueryID: query.ID,
+		CacheTime:     inlineCacheTime,
+		IsPersonal:    true,
+		Results:       []interface{}{},
+	}
+
+	text := strings.TrimSpace(query.Query)
+	if text != "" {
+		cfg, err := b.GetOrCreateUserConfig(uint(query.From.ID))
+		if err != nil {
+			return err
+		}
+
+		translated, err := b.cachedTranslate(cfg, text)
+		if err != nil {
+			return translationError(err)
+		}
+		answer.Results = append(answer.Results,
+			inlineArticle(inlineResultTranslate, fmt.Sprintf("%v -> %v", cfg.Source, cfg.Target), translated),
+		)
+
+		swapped := *cfg
+		swapped.Source, swapped.Target = cfg.Target, cfg.Source
+		if reversed, err := b.cachedTranslate(&swapped, text); err == nil {
+			answer.Results = append(answer.Results,
+				inlineArticle(inlineResultSwap, fmt.Sprintf("%v -> %v", swapped.Source, swapped.Target), reversed),
+			)
+		}
+
+		id := saveResultID(cfg.Source, cfg.Target, query.ID)
+		b.inline.offers.Set(id, translated)
+		save := inlineArticle(id, "Send and save to vocabulary", translated)
+		answer.Results = append(answer.Results, save)
+	}
+
+	_, err := b.bot.Request(answer)
+	return err
+}
+
+func inlineArticle(id string, title string, translated string) tgbotapi.InlineQueryResultArticle {
+	article := tgbotapi.NewInlineQueryResultArticle(id, title, translated)
+	article.Description = translated
+	return article
+}
+
+// cachedTranslate translates text with the user's language pair, reusing
+// recent translations.
+func (b *Bot) cachedTranslate(cfg *db.Config, text string) (string, error) {
+	key := cacheKey(cfg.UserID, cfg.Source, cfg.Target, text)
+	if translated, ok := b.inline.cache.Get(key); ok {
+		return translated, nil
+	}
+
+	translated, err := b.translate(cfg.UserID, cfg, text, false)
+	if err != nil {
+		return "", err
+	}
+	b.inline.cache.Set(key, translated)
+
+	return translated, nil
+}
+
+// forgetTranslations drops the cached translations of the user, e.g. when
+// the user's glossary changes.
+func (b *Bot) forgetTranslations(userid uint) {
+	if b.inline != nil {
+		b.inline.cache.DeletePrefix(userCacheKeyPrefix(userid))
+	}
+}
+
+// saveResultID identifies the save result of an inline query and names the
+// language pair of its translation.
+func saveResultID(source string, target string, queryID string) string {
+	return strings.Join([]string{inlineResultSave, source, target, queryID}, ":")
+}
+
+// handleChosenInlineResult saves the translation when the user picked the
+// "save" result. The translation the user saw is saved, it is not
+// translated again.
+func (b *Bot) handleChosenInlineResult(result *tgbotapi.ChosenInlineResult) error {
+	parts := strings.SplitN(result.ResultID, ":", 4)
+	if len(parts) != 4 || parts[0] != inlineResultSave {
+		return nil
+	}
+
+	translated, ok := b.inline.offers.Get(result.ResultID)
+	if !ok {
+		return fmt.Errorf("translation of inline result %v is not kept anymore", result.ResultID)
+	}
+
+	return b.repo.CreateTranslation(&db.Translation{
+		UserID:     uint(result.From.ID),
+		SourceText: strings.TrimSpace(result.Query),
+		TargetText: translated,
+		Source:     parts[1],
+		Target:     parts[2],
+	})
+}
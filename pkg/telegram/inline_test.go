@@ -0,0 +1,44 @@
+package telegram
+
+import (
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/maxik12233/english-helper-telegrambot/pkg/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleChosenInlineResult(t *testing.T) {
+
+	botAPI, api := newTestAPI(t)
+	repo := db.NewMemoryRepo()
+	translator := &batchTranslator{dictionary: map[string]string{"car": "машина"}}
+	b := &Bot{bot: botAPI, repo: repo, translateService: translator, inline: newInlineState()}
+
+	user := &tgbotapi.User{ID: 1}
+	assert.NoError(t, b.answerInlineQuery(&tgbotapi.InlineQuery{ID: "42", From: user, Query: "car"}))
+	assert.Equal(t, []string{"answerInlineQuery"}, api.called())
+
+	// The translation changes after the results were shown
+	translator.dictionary["car"] = "автомобиль"
+	b.inline.cache = newTranslationCache(inlineCacheSize, inlineCacheTTL)
+
+	id := saveResultID("en", "ru", "42")
+	assert.NoError(t, b.handleChosenInlineResult(&tgbotapi.ChosenInlineResult{ResultID: id, From: user, Query: "car"}))
+
+	translations, err := repo.GetTranslations(1)
+	assert.NoError(t, err)
+	if assert.Len(t, translations, 1) {
+		assert.Equal(t, "car", translations[0].SourceText)
+		assert.Equal(t, "машина", translations[0].TargetText)
+		assert.Equal(t, "en", translations[0].Source)
+		assert.Equal(t, "ru", translations[0].Target)
+	}
+
+	// Other results are not saved, neither are unknown ones
+	assert.NoError(t, b.handleChosenInlineResult(&tgbotapi.ChosenInlineResult{ResultID: inlineResultTranslate, From: user, Query: "car"}))
+	assert.Error(t, b.handleChosenInlineResult(&tgbotapi.ChosenInlineResult{ResultID: saveResultID("en", "ru", "7"), From: user, Query: "car"}))
+	translations, err = repo.GetTranslations(1)
+	assert.NoError(t, err)
+	assert.Len(t, translations, 1)
+}
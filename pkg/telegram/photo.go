@@ -0,0 +1,148 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/maxik12233/english-helper-telegrambot/pkg/db"
+)
+
+const (
+	callbackSaveWord = "save"
+
+	recognitionTimeout = time.Minute
+
+	// maxWordButtons keeps the keyboard under a long menu readable
+	maxWordButtons = 12
+	wordButtonsRow = 3
+)
+
+// largestPhoto picks the best size of a photo that bots can download.
+func largestPhoto(sizes []tgbotapi.PhotoSize) *tgbotapi.PhotoSize {
+	var best *tgbotapi.PhotoSize
+	for i := range sizes {
+		size := &sizes[i]
+		if size.FileSize > maxFileSize {
+			continue
+		}
+		if best == nil || size.Width*size.Height > best.Width*best.Height {
+			best = size
+		}
+	}
+	return best
+}
+
+// recognize replaces the text of a photo content with the text recognized
+// on it in the language lang. The recognized text is quoted in the reply.
+func (b *Bot) recognize(c content, lang string) (content, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), recognitionTimeout)
+	defer cancel()
+
+	image, err := b.downloadFile(ctx, c.photo.FileID)
+	if err != nil {
+		return c, ErrRecognition
+	}
+
+	text, err := b.recognizer.Recognize(ctx, image, lang)
+	if err != nil {
+		return c, ErrRecognition
+	}
+
+	c.text = text
+	c.entities = nil
+	c.quote = true
+
+	return c, nil
+}
+
+// recognizedWords returns unique words of the text that are worth saving,
+// in order of appearance.
+func recognizedWords(text string) []string {
+	var words []string
+	seen := make(map[string]bool)
+
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '-' && r != '\''
+	})
+	for _, field := range fields {
+		word := strings.ToLower(strings.Trim(field, "-'"))
+		if len([]rune(word)) < 2 || seen[word] {
+			continue
+		}
+		seen[word] = true
+		words = append(words, word)
+		if len(words) == maxWordButtons {
+			break
+		}
+	}
+
+	return words
+}
+
+// saveWordsKeyboard returns buttons saving recognized words to vocabulary.
+func saveWordsKeyboard(text string, source string, target string) *tgbotapi.InlineKeyboardMarkup {
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for _, word := range recognizedWords(text) {
+		data, ok := callbackData(callbackSaveWord, source, target, word)
+		if !ok {
+			continue
+		}
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData("➕ "+word, data))
+		if len(row) == wordButtonsRow {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return &keyboard
+}
+
+// handleSaveWordCallback translates the word of the button and saves it to
+// vocabulary of the user who pressed it.
+func (b *Bot) handleSaveWordCallback(query *tgbotapi.CallbackQuery) error {
+
+	_, args := parseCallbackData(query.Data, 3)
+	if len(args) != 3 {
+		return ErrInternal
+	}
+	cfg := &db.Config{
+		UserID: uint(query.From.ID),
+		Source: args[0],
+		Target: args[1],
+	}
+	word := args[2]
+
+	translated, err := b.cachedTranslate(cfg, word)
+	if err != nil {
+		return translationError(err)
+	}
+
+	if err := b.repo.CreateTranslation(&db.Translation{
+		UserID:     cfg.UserID,
+		ChatID:     query.Message.Chat.ID,
+		SourceText: word,
+		TargetText: translated,
+		Source:     cfg.Source,
+		Target:     cfg.Target,
+	}); err != nil {
+		return ErrCreatingTranslation
+	}
+
+	b.answerCallback(query, fmt.Sprintf("Saved: %v - %v", word, translated))
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package telegram
+
+import (
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/maxik12233/english-helper-telegrambot/pkg/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecognizedWords(t *testing.T) {
+
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{
+			name: "Punctuation, numbers and duplicates are dropped",
+			text: "SOUP of the day - 5$\nSoup, bread & butter!",
+			want: []string{"soup", "of", "the", "day", "bread", "butter"},
+		},
+		{
+			name: "Hyphens and apostrophes are kept inside words",
+			text: "chef's self-made 'pie'",
+			want: []string{"chef's", "self-made", "pie"},
+		},
+		{
+			name: "Nothing to save",
+			text: "1 2 3 a",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, recognizedWords(tt.text))
+		})
+	}
+}
+
+func TestSaveWordsKeyboard(t *testing.T) {
+
+	keyboard := saveWordsKeyboard("red car is fast", "en", "ru")
+
+	if assert.NotNil(t, keyboard) && assert.Len(t, keyboard.InlineKeyboard, 2) {
+		assert.Len(t, keyboard.InlineKeyboard[0], wordButtonsRow)
+		assert.Equal(t, "save:en:ru:red", *keyboard.InlineKeyboard[0][0].CallbackData)
+	}
+
+	assert.Nil(t, saveWordsKeyboard("123", "en", "ru"))
+}
+
+func TestLargestPhoto(t *testing.T) {
+	sizes := []tgbotapi.PhotoSize{
+		{FileID: "small", Width: 90, Height: 60},
+		{FileID: "medium", Width: 800, Height: 600},
+		{FileID: "huge", Width: 5000, Height: 4000, FileSize: maxFileSize + 1},
+	}
+
+	assert.Equal(t, "medium", largestPhoto(sizes).FileID)
+	assert.Nil(t, largestPhoto(nil))
+}
+
+func TestSaveWordCallback(t *testing.T) {
+
+	botAPI, api := newTestAPI(t)
+	repo := db.NewMemoryRepo()
+	b := &Bot{
+		bot:              botAPI,
+		repo:             repo,
+		translateService: &batchTranslator{dictionary: map[string]string{"soup": "суп", "bread": "хлеб"}},
+		inline:           newInlineState(),
+	}
+
+	keyboard := saveWordsKeyboard("Soup and bread", "en", "ru")
+	require.NotNil(t, keyboard)
+	button := keyboard.InlineKeyboard[0][2]
+	require.Equal(t, "➕ bread", button.Text)
+
+	b.handleCallbackQuery(callbackQuery(*button.CallbackData))
+	assert.Equal(t, []string{"answerCallbackQuery"}, api.called())
+
+	translations, err := repo.GetTranslations(1)
+	require.NoError(t, err)
+	assert.Equal(t, []db.Translation{
+		{UserID: 1, ChatID: 1, SourceText: "bread", TargetText: "хлеб", Source: "en", Target: "ru"},
+	}, translations)
+}
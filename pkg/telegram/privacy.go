@@ -0,0 +1,136 @@
+package telegram
+
+import (
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/maxik12233/english-helper-telegrambot/pkg/db"
+)
+
+const (
+	callbackForgetMe = "forgetme"
+
+	forgetMeConfirm = "confirm"
+	forgetMeCancel  = "cancel"
+)
+
+// historyEnabled reports whether messages of the user may be saved. Users
+// without a config have not opted out.
+func (b *Bot) historyEnabled(userid uint) (bool, error) {
+	cfg, err := b.repo.GetConfig(userid)
+	if err == db.ErrNotFound {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return cfg.History != db.HistoryOff, nil
+}
+
+// handlePrivacyCommand turns saving of the user's messages on or off, given
+// as the argument, or toggles it.
+func (b *Bot) handlePrivacyCommand(message *tgbotapi.Message) (*tgbotapi.Message, error) {
+
+	arg := strings.ToLower(strings.TrimSpace(message.CommandArguments()))
+	if arg != "" && arg != db.HistoryOn && arg != db.HistoryOff {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Use /privacy on to save your messages, /privacy off to stop saving them or /privacy to toggle.")
+		botmsg, err := b.bot.Send(msg)
+		if err != nil {
+			return nil, ErrSending
+		}
+		return &botmsg, nil
+	}
+
+	cfg, err := b.updateUserConfig(uint(message.From.ID), func(cfg *db.Config) {
+		switch {
+		case arg != "":
+			cfg.History = arg
+		case cfg.History == db.HistoryOff:
+			cfg.History = db.HistoryOn
+		default:
+			cfg.History = db.HistoryOff
+		}
+	})
+	if err != nil {
+		return nil, ErrInternal
+	}
+
+	stored, err := b.repo.CountMessages(uint(message.From.ID))
+	if err != nil {
+		return nil, ErrInternal
+	}
+
+	text := "Your messages are not saved anymore."
+	if cfg.History != db.HistoryOff {
+		text = "Your messages are saved again."
+	}
+	text += fmt.Sprintf(" Messages stored so far: %v, use /forgetme to delete them along with the rest of your data.", stored)
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	botmsg, err := b.bot.Send(msg)
+	if err != nil {
+		return nil, ErrSending
+	}
+
+	return &botmsg, nil
+}
+
+// handleForgetMeCommand asks the user to confirm deletion of all their data.
+func (b *Bot) handleForgetMeCommand(message *tgbotapi.Message) (*tgbotapi.Message, error) {
+
+	confirm, _ := callbackData(callbackForgetMe, forgetMeConfirm)
+	cancel, _ := callbackData(callbackForgetMe, forgetMeCancel)
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, "This deletes your messages, vocabulary, glossary, settings and challenge scores. It cannot be undone.")
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("Delete my data", confirm),
+		tgbotapi.NewInlineKeyboardButtonData("Cancel", cancel),
+	))
+	botmsg, err := b.bot.Send(msg)
+	if err != nil {
+		return nil, ErrSending
+	}
+
+	return &botmsg, nil
+}
+
+func (b *Bot) handleForgetMeCallback(query *tgbotapi.CallbackQuery) error {
+
+	_, args := parseCallbackData(query.Data, 1)
+	if len(args) != 1 {
+		return ErrInternal
+	}
+
+	text := "Nothing was deleted."
+	if args[0] == forgetMeConfirm {
+		userid := uint(query.From.ID)
+		history, err := b.historyEnabled(userid)
+		if err != nil {
+			return ErrInternal
+		}
+		if err := b.repo.DeleteUserData(userid); err != nil {
+			return ErrInternal
+		}
+		text = "All your data is deleted."
+
+		// The opt-out goes with the settings, it is kept in a new config
+		if !history {
+			cfg := defaultConfig(userid)
+			cfg.History = db.HistoryOff
+			if err := b.repo.CreateConfig(cfg); err != nil {
+				return ErrInternal
+			}
+			text += " Your messages are still not saved."
+		}
+	}
+
+	// Replacing the text also removes the buttons
+	edit := tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, text)
+	if _, err := b.bot.Send(edit); err != nil {
+		return ErrSending
+	}
+	b.answerCallback(query, "")
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package telegram
+
+import (
+	"testing"
+
+	"github.com/maxik12233/english-helper-telegrambot/pkg/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryEnabled(t *testing.T) {
+	repo := db.NewMemoryRepo()
+	require.NoError(t, repo.CreateConfig(&db.Config{UserID: 2, Source: "en", Target: "ru", Mode: modeLearn}))
+	require.NoError(t, repo.CreateConfig(&db.Config{UserID: 3, Source: "en", Target: "ru", Mode: modeLearn, History: db.HistoryOff}))
+	require.NoError(t, repo.CreateConfig(&db.Config{UserID: 4, Source: "en", Target: "ru", Mode: modeLearn, History: db.HistoryOn}))
+	b := &Bot{repo: repo}
+
+	tests := []struct {
+		name   string
+		userid uint
+		want   bool
+	}{
+		{name: "No config", userid: 1, want: true},
+		{name: "Not set", userid: 2, want: true},
+		{name: "Opted out", userid: 3, want: false},
+		{name: "Opted in again", userid: 4, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enabled, err := b.historyEnabled(tt.userid)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, enabled)
+		})
+	}
+
+	// Checking does not create a config
+	_, err := repo.GetConfig(1)
+	assert.Equal(t, db.ErrNotFound, err)
+}
+
+func TestForgetMeCallback(t *testing.T) {
+
+	botAPI, api := newTestAPI(t)
+	repo := db.NewMemoryRepo()
+	b := &Bot{bot: botAPI, repo: repo}
+
+	for _, userid := range []uint{1, 2} {
+		require.NoError(t, repo.CreateMessage(&db.Message{UserID: userid, ChatID: int64(userid), Text: "car"}))
+		require.NoError(t, repo.CreateTranslation(&db.Translation{UserID: userid, ChatID: int64(userid), SourceText: "car", TargetText: "машина", Source: "en", Target: "ru"}))
+		require.NoError(t, repo.CreateConfig(defaultConfig(userid)))
+	}
+
+	cancel, _ := callbackData(callbackForgetMe, forgetMeCancel)
+	b.handleCallbackQuery(callbackQuery(cancel))
+	n, err := repo.CountMessages(1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	confirm, _ := callbackData(callbackForgetMe, forgetMeConfirm)
+	b.handleCallbackQuery(callbackQuery(confirm))
+	assert.Equal(t, []string{"editMessageText", "answerCallbackQuery", "editMessageText", "answerCallbackQuery"}, api.called())
+
+	n, err = repo.CountMessages(1)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	translations, err := repo.GetTranslations(1)
+	require.NoError(t, err)
+	assert.Empty(t, translations)
+	_, err = repo.GetConfig(1)
+	assert.Equal(t, db.ErrNotFound, err)
+
+	// Other users keep their data
+	n, err = repo.CountMessages(2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	translations, err = repo.GetTranslations(2)
+	require.NoError(t, err)
+	assert.Len(t, translations, 1)
+
+	// Users who stopped saving of their messages keep it stopped
+	cfg := defaultConfig(1)
+	cfg.Mode = modeTranslate
+	cfg.History = db.HistoryOff
+	require.NoError(t, repo.CreateConfig(cfg))
+	b.handleCallbackQuery(callbackQuery(confirm))
+
+	cfg, err = repo.GetConfig(1)
+	require.NoError(t, err)
+	assert.Equal(t, db.HistoryOff, cfg.History)
+	assert.Equal(t, modeDefault, cfg.Mode)
+	enabled, err := b.historyEnabled(1)
+	require.NoError(t, err)
+	assert.False(t, enabled)
+}
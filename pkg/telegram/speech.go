@@ -0,0 +1,92 @@
+package telegram
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/maxik12233/english-helper-telegrambot/pkg/tts"
+)
+
+const (
+	callbackPronounce = "pronounce"
+
+	speechTimeout = 30 * time.Second
+)
+
+// pronounceKeyboard returns a button that sends the pronunciation of text,
+// or nil if speech is disabled or the text is too long for the button.
+func (b *Bot) pronounceKeyboard(text string, lang string) *tgbotapi.InlineKeyboardMarkup {
+	if b.speech == nil {
+		return nil
+	}
+
+	text = strings.TrimSpace(text)
+	if text == "" || strings.Contains(text, "\n") {
+		return nil
+	}
+
+	data, ok := callbackData(callbackPronounce, lang, text)
+	if !ok {
+		return nil
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🔊 Pronounce", data),
+	))
+
+	return &keyboard
+}
+
+// handlePronounceCallback sends a voice message with the word of the button.
+func (b *Bot) handlePronounceCallback(query *tgbotapi.CallbackQuery) error {
+
+	_, args := parseCallbackData(query.Data, 2)
+	if b.speech == nil || len(args) != 2 {
+		return ErrSpeech
+	}
+	lang, text := args[0], args[1]
+
+	ctx, cancel := context.WithTimeout(context.Background(), speechTimeout)
+	defer cancel()
+
+	audio, err := b.speech.Synthesize(ctx, text, lang)
+	if err != nil {
+		return ErrSpeech
+	}
+
+	if _, err := b.bot.Send(pronunciationMessage(query.Message, audio)); err != nil {
+		return ErrSending
+	}
+
+	b.answerCallback(query, "")
+
+	return nil
+}
+
+// audioExtensions name audio files by their detected content type.
+var audioExtensions = map[string]string{
+	"audio/wave":      ".wav",
+	"audio/mpeg":      ".mp3",
+	"application/ogg": ".ogg",
+}
+
+// pronunciationMessage replies to message with the audio. Telegram plays only
+// OGG/Opus as voice messages, other audio is sent as a file to play.
+func pronunciationMessage(message *tgbotapi.Message, audio []byte) tgbotapi.Chattable {
+	if tts.IsOpus(audio) {
+		voice := tgbotapi.NewVoice(message.Chat.ID, tgbotapi.FileBytes{Name: "pronunciation.ogg", Bytes: audio})
+		voice.ReplyToMessageID = message.MessageID
+		return voice
+	}
+
+	ext, ok := audioExtensions[http.DetectContentType(audio)]
+	if !ok {
+		ext = ".wav"
+	}
+	file := tgbotapi.NewAudio(message.Chat.ID, tgbotapi.FileBytes{Name: "pronunciation" + ext, Bytes: audio})
+	file.ReplyToMessageID = message.MessageID
+	return file
+}
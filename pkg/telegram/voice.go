@@ -0,0 +1,71 @@
+package telegram
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// maxVoiceDuration in seconds keeps transcription reasonably fast
+	maxVoiceDuration = 5 * 60
+	// maxFileSize is the largest file bots can download from Telegram
+	maxFileSize = 20 << 20
+
+	transcriptionTimeout = 2 * time.Minute
+)
+
+// transcribe replaces the text of a voice message content with its
+// transcript in the language lang. The transcript is quoted in the reply.
+func (b *Bot) transcribe(c content, lang string) (content, error) {
+	if c.voice.Duration > maxVoiceDuration {
+		return c, ErrVoiceTooLong
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), transcriptionTimeout)
+	defer cancel()
+
+	audio, err := b.downloadFile(ctx, c.voice.FileID)
+	if err != nil {
+		return c, ErrTranscription
+	}
+
+	text, err := b.transcriber.Transcribe(ctx, audio, lang)
+	if err != nil {
+		return c, ErrTranscription
+	}
+
+	c.text = text
+	c.entities = nil
+	c.quote = true
+
+	return c, nil
+}
+
+func (b *Bot) downloadFile(ctx context.Context, fileID string) ([]byte, error) {
+
+	url, err := b.bot.GetFileDirectURL(fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := b.bot.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &tgbotapi.Error{Code: resp.StatusCode, Message: resp.Status}
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, maxFileSize))
+}
@@ -0,0 +1,66 @@
+package tts
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Cache keeps synthesized audio on disk, so every word is synthesized once.
+type Cache struct {
+	provider Provider
+	dir      string
+}
+
+func NewCache(provider Provider, dir string) (Provider, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &Cache{
+		provider: provider,
+		dir:      dir,
+	}, nil
+}
+
+func (c *Cache) Synthesize(ctx context.Context, text string, lang string) ([]byte, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, ErrEmptyText
+	}
+
+	path := c.path(text, lang)
+	if audio, err := os.ReadFile(path); err == nil && len(audio) > 0 {
+		return audio, nil
+	}
+
+	audio, err := c.provider.Synthesize(ctx, text, lang)
+	if err != nil {
+		return nil, err
+	}
+
+	// Written to a temporary file first, so a concurrent read never sees
+	// a partial file. Failing to cache is not a reason to fail the request.
+	if tmp, err := os.CreateTemp(c.dir, "*.tmp"); err == nil {
+		_, err = tmp.Write(audio)
+		if closeErr := tmp.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Rename(tmp.Name(), path)
+		}
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+	}
+
+	return audio, nil
+}
+
+func (c *Cache) path(text string, lang string) string {
+	sum := sha256.Sum256([]byte(lang + "\x00" + strings.ToLower(text)))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:]))
+}
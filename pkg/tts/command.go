@@ -0,0 +1,46 @@
+package tts
+
+import (
+	"context"
+	"strings"
+
+	"github.com/maxik12233/english-helper-telegrambot/pkg/cmdrunner"
+)
+
+// DefaultCommand speaks with espeak-ng, which writes wav to stdout, so the
+// pronunciation is sent as an audio file. "--" keeps text starting with '-'
+// from being read as an option.
+const DefaultCommand = "espeak-ng -v {lang} --stdout -- {text}"
+
+// CommandProvider runs a local synthesizer that writes audio to stdout.
+// {text} and {lang} in its arguments are replaced with the text and its
+// language.
+type CommandProvider struct {
+	command cmdrunner.Command
+}
+
+func NewCommandProvider(line string) (Provider, error) {
+	command, err := cmdrunner.Parse(line)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CommandProvider{command: command}, nil
+}
+
+func (p *CommandProvider) Synthesize(ctx context.Context, text string, lang string) ([]byte, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, ErrEmptyText
+	}
+
+	audio, err := p.command.Run(ctx, map[string]string{"text": text, "lang": lang}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(audio) == 0 {
+		return nil, ErrEmptyAudio
+	}
+
+	return audio, nil
+}
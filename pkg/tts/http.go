@@ -0,0 +1,79 @@
+package tts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxAudioSize limits audio read from a speech server, voice messages of
+// single words are much smaller.
+const maxAudioSize = 10 << 20
+
+type speechRequest struct {
+	Text string `json:"text"`
+	Lang string `json:"lang"`
+}
+
+// HTTPProvider posts {"text": ..., "lang": ...} as json to a speech server
+// and reads audio from the response body.
+type HTTPProvider struct {
+	url    string
+	client *http.Client
+}
+
+func NewHTTPProvider(url string, client *http.Client) (Provider, error) {
+	if url == "" {
+		return nil, errEmptyURL
+	}
+	if client == nil {
+		return nil, errNilHttpClient
+	}
+
+	return &HTTPProvider{
+		url:    url,
+		client: client,
+	}, nil
+}
+
+func (p *HTTPProvider) Synthesize(ctx context.Context, text string, lang string) ([]byte, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, ErrEmptyText
+	}
+
+	body, err := json.Marshal(speechRequest{Text: text, Lang: lang})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("speech server responded with %v", resp.Status)
+	}
+
+	audio, err := io.ReadAll(io.LimitReader(resp.Body, maxAudioSize))
+	if err != nil {
+		return nil, err
+	}
+	if len(audio) == 0 {
+		return nil, ErrEmptyAudio
+	}
+
+	return audio, nil
+}
@@ -0,0 +1,34 @@
+// Package tts synthesizes speech for words and short phrases.
+package tts
+
+import (
+	"bytes"
+	"context"
+	"errors"
+)
+
+var (
+	ErrEmptyText     = errors.New("Text to pronounce cannot be empty")
+	ErrEmptyAudio    = errors.New("Speech synthesizer returned no audio")
+	errNilHttpClient = errors.New("Http Client cannot be nil")
+	errEmptyURL      = errors.New("URL of the speech server cannot be empty")
+)
+
+// Provider synthesizes speech for text in the language lang (e.g. "en").
+// Telegram plays only OGG/Opus audio as voice messages, other formats like
+// wav or mp3 are sent as audio files.
+type Provider interface {
+	Synthesize(ctx context.Context, text string, lang string) ([]byte, error)
+}
+
+// IsOpus reports whether audio is an OGG/Opus stream. Its first page holds
+// the Opus header.
+func IsOpus(audio []byte) bool {
+	if !bytes.HasPrefix(audio, []byte("OggS")) {
+		return false
+	}
+	if len(audio) > 64 {
+		audio = audio[:64]
+	}
+	return bytes.Contains(audio, []byte("OpusHead"))
+}
@@ -0,0 +1,99 @@
+package tts
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/maxik12233/english-helper-telegrambot/pkg/cmdrunner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingProvider struct {
+	calls int
+}
+
+func (p *countingProvider) Synthesize(ctx context.Context, text string, lang string) ([]byte, error) {
+	p.calls++
+	return []byte(lang + ":" + text), nil
+}
+
+func TestCache(t *testing.T) {
+	provider := &countingProvider{}
+	cache, err := NewCache(provider, t.TempDir())
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		audio, err := cache.Synthesize(context.Background(), "Car", "en")
+		require.NoError(t, err)
+		assert.Equal(t, "en:Car", string(audio))
+	}
+	assert.Equal(t, 1, provider.calls)
+
+	_, err = cache.Synthesize(context.Background(), "car", "de")
+	require.NoError(t, err)
+	assert.Equal(t, 2, provider.calls)
+
+	_, err = cache.Synthesize(context.Background(), " ", "en")
+	assert.Equal(t, ErrEmptyText, err)
+}
+
+func TestCommandProvider(t *testing.T) {
+	provider, err := NewCommandProvider("echo -n {lang}:{text}")
+	require.NoError(t, err)
+
+	audio, err := provider.Synthesize(context.Background(), "red car", "en")
+	require.NoError(t, err)
+	assert.Equal(t, "en:red car", string(audio))
+
+	// Text must not become an option of the synthesizer
+	provider, err = NewCommandProvider("echo -n {text}")
+	require.NoError(t, err)
+	_, err = provider.Synthesize(context.Background(), "-w/tmp/x", "en")
+	assert.Equal(t, cmdrunner.ErrOptionValue, err)
+
+	provider, err = NewCommandProvider("echo -n -- {text}")
+	require.NoError(t, err)
+	audio, err = provider.Synthesize(context.Background(), "-w/tmp/x", "en")
+	require.NoError(t, err)
+	assert.Equal(t, "-- -w/tmp/x", string(audio))
+}
+
+func TestHTTPProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req speechRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if req.Lang == "xx" {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		w.Write([]byte(req.Lang + ":" + req.Text))
+	}))
+	defer server.Close()
+
+	provider, err := NewHTTPProvider(server.URL, server.Client())
+	require.NoError(t, err)
+
+	audio, err := provider.Synthesize(context.Background(), "car", "en")
+	require.NoError(t, err)
+	assert.Equal(t, "en:car", string(audio))
+
+	_, err = provider.Synthesize(context.Background(), "car", "xx")
+	assert.Error(t, err)
+
+	_, err = NewHTTPProvider("", server.Client())
+	assert.Equal(t, errEmptyURL, err)
+}
+
+func TestIsOpus(t *testing.T) {
+	assert.True(t, IsOpus([]byte("OggS\x00\x02\x00\x00\x00\x00\x00\x00\x00\x00\x01\x13OpusHead\x01")))
+	assert.False(t, IsOpus([]byte("OggS\x00\x02\x01\x1evorbis")))
+	assert.False(t, IsOpus([]byte("RIFF\x24\x00\x00\x00WAVEfmt OpusHead")))
+	assert.False(t, IsOpus(nil))
+}
@@ -0,0 +1,253 @@
+package vocabulary
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// ankiModelID is the id of the note type of exported notes. It is fixed,
+// so that notes imported again update the ones imported before.
+const ankiModelID = 1721463517
+
+// ankiSchema is the legacy collection schema (version 11) which every Anki
+// version can import.
+var ankiSchema = []string{
+	`CREATE TABLE col (
+		id integer primary key, crt integer not null, mod integer not null,
+		scm integer not null, ver integer not null, dty integer not null,
+		usn integer not null, ls integer not null, conf text not null,
+		models text not null, decks text not null, dconf text not null,
+		tags text not null
+	)`,
+	`CREATE TABLE notes (
+		id integer primary key, guid text not null, mid integer not null,
+		mod integer not null, usn integer not null, tags text not null,
+		flds text not null, sfld integer not null, csum integer not null,
+		flags integer not null, data text not null
+	)`,
+	`CREATE TABLE cards (
+		id integer primary key, nid integer not null, did integer not null,
+		ord integer not null, mod integer not null, usn integer not null,
+		type integer not null, queue integer not null, due integer not null,
+		ivl integer not null, factor integer not null, reps integer not null,
+		lapses integer not null, left integer not null, odue integer not null,
+		odid integer not null, flags integer not null, data text not null
+	)`,
+	`CREATE TABLE revlog (
+		id integer primary key, cid integer not null, usn integer not null,
+		ease integer not null, ivl integer not null, lastIvl integer not null,
+		factor integer not null, time integer not null, type integer not null
+	)`,
+	`CREATE TABLE graves (usn integer not null, oid integer not null, type integer not null)`,
+	`CREATE INDEX ix_notes_usn ON notes (usn)`,
+	`CREATE INDEX ix_cards_usn ON cards (usn)`,
+	`CREATE INDEX ix_revlog_usn ON revlog (usn)`,
+	`CREATE INDEX ix_cards_nid ON cards (nid)`,
+	`CREATE INDEX ix_cards_sched ON cards (did, queue, due)`,
+	`CREATE INDEX ix_revlog_cid ON revlog (cid)`,
+	`CREATE INDEX ix_notes_csum ON notes (csum)`,
+}
+
+const ankiConf = `{"activeDecks":[1],"curDeck":1,"newSpread":0,"collapseTime":1200,"timeLim":0,` +
+	`"estTimes":true,"dueCounts":true,"curModel":null,"nextPos":1,"sortType":"noteFld",` +
+	`"sortBackwards":false,"addToCur":true}`
+
+const ankiDeckConf = `{"1":{"id":1,"name":"Default","mod":0,"usn":0,"maxTaken":60,"autoplay":true,` +
+	`"timer":0,"replayq":true,"dyn":false,` +
+	`"new":{"bury":true,"delays":[1,10],"initialFactor":2500,"ints":[1,4,7],"order":1,"perDay":20,"separate":true},` +
+	`"lapse":{"delays":[10],"leechAction":0,"leechFails":8,"minInt":1,"mult":0},` +
+	`"rev":{"bury":true,"ease4":1.3,"fuzz":0.05,"ivlFct":1,"maxIvl":36500,"minSpace":1,"perDay":100}}}`
+
+// WriteAnki writes the words as an Anki package (.apkg) with a deck of the
+// given name. Every word is a note with the Front and Back fields tagged
+// with its language pair. The bot keeps no review history of single words,
+// so all cards are new.
+func WriteAnki(w io.Writer, deck string, words []Word) error {
+
+	dir, err := os.MkdirTemp("", "anki")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "collection.anki2")
+	if err := writeAnkiCollection(path, deck, words, time.Now()); err != nil {
+		return fmt.Errorf("writing anki collection: %w", err)
+	}
+
+	collection, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer collection.Close()
+
+	zw := zip.NewWriter(w)
+	fw, err := zw.Create("collection.anki2")
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(fw, collection); err != nil {
+		return err
+	}
+	// The package has no media files
+	fw, err = zw.Create("media")
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(fw, "{}"); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+func writeAnkiCollection(path string, deck string, words []Word, now time.Time) error {
+	ctx := context.Background()
+
+	conn, err := sql.Open("sqlite", path)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, statement := range ankiSchema {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+
+	deckID := ankiDeckID(deck)
+	models, err := ankiModels(deckID, now)
+	if err != nil {
+		return err
+	}
+	decks, err := ankiDecks(deckID, deck, now)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO col VALUES (1, ?, ?, ?, 11, 0, 0, 0, ?, ?, ?, ?, '{}')`,
+		now.Unix(), now.UnixMilli(), now.UnixMilli(), ankiConf, models, decks, ankiDeckConf)
+	if err != nil {
+		return err
+	}
+
+	// Ids of notes and cards are creation times in milliseconds
+	base := now.UnixMilli()
+	for i, word := range words {
+		id := base + int64(i)
+		front := html.EscapeString(word.Front)
+		tags := ""
+		if t := word.Tags(); len(t) > 0 {
+			tags = " " + strings.Join(t, " ") + " "
+		}
+
+		_, err := tx.ExecContext(ctx, `INSERT INTO notes VALUES (?, ?, ?, ?, -1, ?, ?, ?, ?, 0, '')`,
+			id, ankiGUID(word), ankiModelID, now.Unix(), tags,
+			front+"\x1f"+html.EscapeString(word.Back), front, ankiChecksum(front))
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `INSERT INTO cards VALUES (?, ?, ?, 0, ?, -1, 0, 0, ?, 0, 0, 0, 0, 0, 0, 0, 0, '')`,
+			id, id, deckID, now.Unix(), i+1)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ankiDeckID derives the id from the name, so that a deck imported again is
+// merged with the one imported before.
+func ankiDeckID(name string) int64 {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return 1<<30 + int64(h.Sum32()%(1<<30))
+}
+
+// ankiGUID identifies the note across imports.
+func ankiGUID(w Word) string {
+	sum := sha1.Sum([]byte(w.Key()))
+	return base64.RawURLEncoding.EncodeToString(sum[:])[:10]
+}
+
+// ankiChecksum is the number Anki finds duplicate notes by: the first 8 hex
+// digits of the sha1 of the first field.
+func ankiChecksum(field string) int64 {
+	sum := sha1.Sum([]byte(field))
+	n, _ := strconv.ParseInt(hex.EncodeToString(sum[:4]), 16, 64)
+	return n
+}
+
+func ankiModels(deckID int64, now time.Time) (string, error) {
+	field := func(name string, ord int) map[string]any {
+		return map[string]any{"name": name, "ord": ord, "font": "Arial", "size": 20, "media": []any{}, "rtl": false, "sticky": false}
+	}
+	model := map[string]any{
+		"id":        ankiModelID,
+		"name":      "English Helper",
+		"type":      0,
+		"mod":       now.Unix(),
+		"usn":       -1,
+		"did":       deckID,
+		"sortf":     0,
+		"tags":      []any{},
+		"vers":      []any{},
+		"flds":      []any{field("Front", 0), field("Back", 1)},
+		"req":       []any{[]any{0, "all", []any{0}}},
+		"css":       ".card { font-family: arial; font-size: 20px; text-align: center; }",
+		"latexPre":  "\\documentclass[12pt]{article}\n\\pagestyle{empty}\n\\begin{document}\n",
+		"latexPost": "\\end{document}",
+		"tmpls": []any{map[string]any{
+			"name":  "Card 1",
+			"ord":   0,
+			"qfmt":  "{{Front}}",
+			"afmt":  "{{FrontSide}}<hr id=answer>{{Back}}",
+			"bqfmt": "",
+			"bafmt": "",
+			"did":   nil,
+		}},
+	}
+
+	data, err := json.Marshal(map[string]any{strconv.Itoa(ankiModelID): model})
+	return string(data), err
+}
+
+func ankiDecks(deckID int64, name string, now time.Time) (string, error) {
+	deck := func(id int64, name string) map[string]any {
+		return map[string]any{
+			"id": id, "name": name, "desc": "", "mod": now.Unix(), "usn": -1, "conf": 1, "dyn": 0,
+			"collapsed": false, "extendNew": 10, "extendRev": 50,
+			"newToday": []int{0, 0}, "revToday": []int{0, 0}, "lrnToday": []int{0, 0}, "timeToday": []int{0, 0},
+		}
+	}
+
+	data, err := json.Marshal(map[string]any{
+		"1":                           deck(1, "Default"),
+		strconv.FormatInt(deckID, 10): deck(deckID, name),
+	})
+	return string(data), err
+}
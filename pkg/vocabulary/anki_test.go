@@ -0,0 +1,76 @@
+package vocabulary
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteAnki(t *testing.T) {
+
+	words := []Word{
+		{Front: "car", Back: "машина", Source: "en", Target: "ru"},
+		{Front: "a < b", Back: "а < б", Source: "en", Target: "ru"},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteAnki(&buf, "English Helper", words))
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	files := make(map[string][]byte)
+	for _, f := range zr.File {
+		r, err := f.Open()
+		require.NoError(t, err)
+		files[f.Name], err = io.ReadAll(r)
+		require.NoError(t, err)
+		r.Close()
+	}
+	assert.Equal(t, "{}", string(files["media"]))
+
+	path := filepath.Join(t.TempDir(), "collection.anki2")
+	require.NoError(t, os.WriteFile(path, files["collection.anki2"], 0o600))
+	conn, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	var ver int
+	var decks string
+	require.NoError(t, conn.QueryRow(`SELECT ver, decks FROM col`).Scan(&ver, &decks))
+	assert.Equal(t, 11, ver)
+	var parsed map[string]map[string]any
+	require.NoError(t, json.Unmarshal([]byte(decks), &parsed))
+	deckID := ankiDeckID("English Helper")
+	assert.Equal(t, "English Helper", parsed[strconv.FormatInt(deckID, 10)]["name"])
+
+	rows, err := conn.Query(`SELECT n.flds, n.tags, n.guid, c.did FROM notes n JOIN cards c ON c.nid = n.id ORDER BY c.due`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var fields, tags, guids []string
+	for rows.Next() {
+		var flds, tag, guid string
+		var did int64
+		require.NoError(t, rows.Scan(&flds, &tag, &guid, &did))
+		assert.Equal(t, deckID, did)
+		fields = append(fields, flds)
+		tags = append(tags, tag)
+		guids = append(guids, guid)
+	}
+	require.NoError(t, rows.Err())
+
+	assert.Equal(t, []string{"car\x1fмашина", "a &lt; b\x1fа &lt; б"}, fields)
+	assert.Equal(t, []string{" en-ru ", " en-ru "}, tags)
+	// Guids do not change between exports
+	assert.Equal(t, []string{ankiGUID(words[0]), ankiGUID(words[1])}, guids)
+	assert.NotEqual(t, guids[0], guids[1])
+}
@@ -0,0 +1,200 @@
+package vocabulary
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"html"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("File format is not supported")
+	ErrNoWords           = errors.New("No words were found in the file")
+)
+
+// Parsed is a word list read from a file. Words with an empty Back have no
+// translation yet, Source and Target are empty unless the file names them.
+type Parsed struct {
+	Words []Word
+	// Skipped counts rows without a word
+	Skipped int
+}
+
+// Header names of the columns, compared ignoring case.
+var (
+	frontColumns  = []string{"front", "word", "term", "text", "question", "source text"}
+	backColumns   = []string{"back", "translation", "definition", "meaning", "answer", "target text"}
+	sourceColumns = []string{"source", "source language", "from"}
+	targetColumns = []string{"target", "target language", "to"}
+)
+
+// columns are positions of the fields in a row, -1 if missing.
+type columns struct {
+	front, back, source, target int
+}
+
+// Anki separators given as names in the #separator header.
+var ankiSeparators = map[string]rune{
+	"tab":       '\t',
+	"comma":     ',',
+	"semicolon": ';',
+	"space":     ' ',
+	"pipe":      '|',
+	"colon":     ':',
+}
+
+var htmlTagRe = regexp.MustCompile(`(?i)<br\s*/?>|<[^>]*>`)
+
+// Parse reads a word list: a CSV or TSV file (the one WriteCSV writes
+// among them), notes exported from Anki as plain text or a Quizlet export
+// with tabs between terms and definitions. The first two columns are taken
+// as the word and its translation unless a header row names them.
+func Parse(data []byte) (*Parsed, error) {
+	if bytes.HasPrefix(data, []byte("PK")) || !utf8.Valid(data) {
+		// Zip archives like Anki packages and other binary files
+		return nil, ErrUnsupportedFormat
+	}
+	data = bytes.TrimPrefix(data, []byte("\uFEFF"))
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+
+	// Anki describes the export in # lines on top of the file
+	var separator rune
+	isHTML := false
+	skip := make(map[int]bool)
+	lines := strings.SplitAfter(text, "\n")
+	start := 0
+	for ; start < len(lines) && strings.HasPrefix(lines[start], "#"); start++ {
+		key, value, ok := strings.Cut(strings.TrimSpace(lines[start][1:]), ":")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "separator":
+			if sep, ok := ankiSeparators[strings.ToLower(value)]; ok {
+				separator = sep
+			} else if r, size := utf8.DecodeRuneInString(value); size == len(value) {
+				separator = r
+			}
+		case "html":
+			isHTML = value == "true"
+		case "guid column", "notetype column", "deck column", "tags column":
+			// Columns are counted from 1
+			if n, err := strconv.Atoi(value); err == nil {
+				skip[n-1] = true
+			}
+		}
+	}
+	body := strings.Join(lines[start:], "")
+	if separator == 0 {
+		separator = detectSeparator(body)
+	}
+
+	r := csv.NewReader(strings.NewReader(body))
+	r.Comma = separator
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	r.TrimLeadingSpace = separator != '\t'
+
+	parsed := &Parsed{}
+	var cols *columns
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, ErrUnsupportedFormat
+		}
+
+		fields := make([]string, 0, len(record))
+		for i, field := range record {
+			if skip[i] {
+				continue
+			}
+			if isHTML {
+				field = html.UnescapeString(htmlTagRe.ReplaceAllString(field, " "))
+			}
+			fields = append(fields, strings.Join(strings.Fields(field), " "))
+		}
+
+		if cols == nil {
+			if header, ok := headerColumns(fields); ok {
+				cols = header
+				continue
+			}
+			cols = &columns{front: 0, back: 1, source: -1, target: -1}
+		}
+
+		word := Word{
+			Front:  at(fields, cols.front),
+			Back:   at(fields, cols.back),
+			Source: at(fields, cols.source),
+			Target: at(fields, cols.target),
+		}
+		if word.Front == "" {
+			parsed.Skipped++
+			continue
+		}
+		parsed.Words = append(parsed.Words, word)
+	}
+
+	if len(parsed.Words) == 0 {
+		return nil, ErrNoWords
+	}
+
+	return parsed, nil
+}
+
+// detectSeparator looks at the first line: tabs are used by Anki and
+// Quizlet, otherwise the more frequent of commas and semicolons wins.
+func detectSeparator(body string) rune {
+	line, _, _ := strings.Cut(strings.TrimLeft(body, "\n"), "\n")
+	switch {
+	case strings.Contains(line, "\t"):
+		return '\t'
+	case strings.Count(line, ";") > strings.Count(line, ","):
+		return ';'
+	case strings.Contains(line, ","):
+		return ','
+	}
+	// A list of words without translations
+	return '\t'
+}
+
+// headerColumns finds the columns if fields are a header row naming at least
+// the word and its translation.
+func headerColumns(fields []string) (*columns, bool) {
+	find := func(names []string) int {
+		for i, field := range fields {
+			for _, name := range names {
+				if strings.EqualFold(field, name) {
+					return i
+				}
+			}
+		}
+		return -1
+	}
+
+	cols := &columns{
+		front:  find(frontColumns),
+		back:   find(backColumns),
+		source: find(sourceColumns),
+		target: find(targetColumns),
+	}
+	if cols.front == -1 || cols.back == -1 {
+		return nil, false
+	}
+	return cols, true
+}
+
+func at(fields []string, i int) string {
+	if i < 0 || i >= len(fields) {
+		return ""
+	}
+	return fields[i]
+}
@@ -0,0 +1,113 @@
+package vocabulary
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		data        string
+		wantWords   []Word
+		wantSkipped int
+		wantErr     error
+	}{
+		{
+			name: "Exported csv",
+			data: "front,back,source,target\ncar,машина,en,ru\n\"hello, world\",привет,en,ru\n",
+			wantWords: []Word{
+				{Front: "car", Back: "машина", Source: "en", Target: "ru"},
+				{Front: "hello, world", Back: "привет", Source: "en", Target: "ru"},
+			},
+		},
+		{
+			name: "Header in another order",
+			data: "\uFEFFTranslation;Word\r\nмашина;car\r\n;house\r\nдом;\r\n",
+			wantWords: []Word{
+				{Front: "car", Back: "машина"},
+				{Front: "house"},
+			},
+			wantSkipped: 1,
+		},
+		{
+			name: "Quizlet export",
+			data: "car\tмашина\nred car\tкрасная машина\n",
+			wantWords: []Word{
+				{Front: "car", Back: "машина"},
+				{Front: "red car", Back: "красная машина"},
+			},
+		},
+		{
+			name: "Anki notes in plain text",
+			data: "#separator:tab\n#html:true\n#guid column:1\n#tags column:4\n" +
+				"a1b2c3\t<b>car</b>\tмашина<br>авто\ten-ru\n" +
+				"d4e5f6\t\"a &amp; b\"\tа и б\t\n",
+			wantWords: []Word{
+				{Front: "car", Back: "машина авто"},
+				{Front: "a & b", Back: "а и б"},
+			},
+		},
+		{
+			name: "Anki separator",
+			data: "#separator:Pipe\ncar|машина\n",
+			wantWords: []Word{
+				{Front: "car", Back: "машина"},
+			},
+		},
+		{
+			name: "Words without translations",
+			data: "car\n\nhouse\n",
+			wantWords: []Word{
+				{Front: "car"},
+				{Front: "house"},
+			},
+		},
+		{
+			name:    "Anki package",
+			data:    "PK\x03\x04collection.anki2",
+			wantErr: ErrUnsupportedFormat,
+		},
+		{
+			name:    "Binary file",
+			data:    "\xff\xfe\x00car",
+			wantErr: ErrUnsupportedFormat,
+		},
+		{
+			name:    "Only a header",
+			data:    "word,translation\n",
+			wantErr: ErrNoWords,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := Parse([]byte(tt.data))
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantWords, parsed.Words)
+			assert.Equal(t, tt.wantSkipped, parsed.Skipped)
+		})
+	}
+}
+
+func TestParseExported(t *testing.T) {
+
+	words := []Word{
+		{Front: "car", Back: "машина", Source: "en", Target: "ru"},
+		{Front: "say \"hi\"", Back: "скажи «привет»", Source: "en", Target: "ru"},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteCSV(&buf, words))
+	parsed, err := Parse(buf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, words, parsed.Words)
+}
@@ -0,0 +1,65 @@
+// Package vocabulary converts saved translations to files other learning
+// tools understand.
+package vocabulary
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+)
+
+// Word is a text and its translation between the source and the target
+// languages.
+type Word struct {
+	Front  string
+	Back   string
+	Source string
+	Target string
+}
+
+// Tags name the language pair of the word, e.g. en-ru.
+func (w Word) Tags() []string {
+	if w.Source == "" || w.Target == "" {
+		return nil
+	}
+	return []string{w.Source + "-" + w.Target}
+}
+
+// Key is equal for words that differ only in case.
+func (w Word) Key() string {
+	return strings.Join([]string{w.Source, w.Target, strings.ToLower(w.Front), strings.ToLower(w.Back)}, "\x00")
+}
+
+// Unique drops repeated words keeping the first of them, the case of the
+// texts is ignored.
+func Unique(words []Word) []Word {
+	seen := make(map[string]bool, len(words))
+	unique := make([]Word, 0, len(words))
+	for _, w := range words {
+		if seen[w.Key()] {
+			continue
+		}
+		seen[w.Key()] = true
+		unique = append(unique, w)
+	}
+	return unique
+}
+
+// csvHeader names the columns written by WriteCSV.
+var csvHeader = []string{"front", "back", "source", "target"}
+
+// WriteCSV writes the words with a header row.
+func WriteCSV(w io.Writer, words []Word) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, word := range words {
+		if err := cw.Write([]string{word.Front, word.Back, word.Source, word.Target}); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
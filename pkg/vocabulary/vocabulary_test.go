@@ -0,0 +1,35 @@
+package vocabulary
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnique(t *testing.T) {
+
+	words := []Word{
+		{Front: "car", Back: "машина", Source: "en", Target: "ru"},
+		{Front: "Car", Back: "Машина", Source: "en", Target: "ru"},
+		{Front: "car", Back: "авто", Source: "en", Target: "ru"},
+		{Front: "car", Back: "машина", Source: "ru", Target: "en"},
+	}
+
+	assert.Equal(t, []Word{words[0], words[2], words[3]}, Unique(words))
+	assert.Empty(t, Unique(nil))
+}
+
+func TestWriteCSV(t *testing.T) {
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteCSV(&buf, []Word{
+		{Front: "car", Back: "машина", Source: "en", Target: "ru"},
+		{Front: "hello, world", Back: `"привет"`, Source: "en", Target: "ru"},
+	}))
+
+	assert.Equal(t, "front,back,source,target\n"+
+		"car,машина,en,ru\n"+
+		"\"hello, world\",\"\"\"привет\"\"\",en,ru\n", buf.String())
+}